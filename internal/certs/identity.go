@@ -0,0 +1,53 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+)
+
+// Identity is the identity of a client authenticated by a certificate that
+// was verified against the client CAs.
+type Identity struct {
+	CommonName         string   `json:"commonName"`
+	Organization       []string `json:"organization,omitempty"`
+	OrganizationalUnit []string `json:"organizationalUnit,omitempty"`
+	DNSNames           []string `json:"dnsNames,omitempty"`
+	URIs               []string `json:"uris,omitempty"`
+	SerialNumber       string   `json:"serialNumber"`
+	Issuer             string   `json:"issuer"`
+}
+
+// PeerIdentity returns the identity of the client of a TLS connection. It
+// returns false if the client sent no certificate or if the certificate was
+// not verified, e.g. with client auth "request".
+func PeerIdentity(state *tls.ConnectionState) (*Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	cert := state.VerifiedChains[0][0]
+	id := &Identity{
+		CommonName:         cert.Subject.CommonName,
+		Organization:       cert.Subject.Organization,
+		OrganizationalUnit: cert.Subject.OrganizationalUnit,
+		DNSNames:           cert.DNSNames,
+		SerialNumber:       cert.SerialNumber.String(),
+		Issuer:             cert.Issuer.String(),
+	}
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+	return id, true
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying the client identity.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the client identity carried by ctx, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
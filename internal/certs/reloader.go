@@ -0,0 +1,208 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// reloadDelay groups the events of a rotation, e.g. the certificate and the
+// key being written one after the other, into a single reload.
+const reloadDelay = 100 * time.Millisecond
+
+// Reloader serves a certificate and the CAs of client certificates loaded
+// from files. The files are loaded again when they change on disk, so that
+// rotated certificates are used without a restart.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	// certificate holds a *tls.Certificate, clientCAs a *x509.CertPool
+	certificate atomic.Value
+	clientCAs   atomic.Value
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewReloader loads the certificate, its key and, if clientCAFile is not
+// empty, the CAs used to verify client certificates.
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	r := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the files again. The previous certificate is kept if any of
+// them is invalid.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate %s: %w", r.certFile, err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return fmt.Errorf("parse certificate %s: %w", r.certFile, err)
+	}
+
+	var pool *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("load client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("load client CA %s: no certificate found", r.clientCAFile)
+		}
+	}
+
+	r.certificate.Store(&cert)
+	r.clientCAs.Store(pool)
+	return nil
+}
+
+// Certificate returns the current certificate.
+func (r *Reloader) Certificate() *tls.Certificate {
+	return r.certificate.Load().(*tls.Certificate)
+}
+
+// ClientCAs returns the current client CAs, nil if there are none.
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	return r.clientCAs.Load().(*x509.CertPool)
+}
+
+// TLSConfig returns a server configuration that uses the current
+// certificate and client CAs for every handshake.
+func (r *Reloader) TLSConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := config.Clone()
+		c.GetConfigForClient = nil
+		c.Certificates = []tls.Certificate{*r.Certificate()}
+		c.ClientAuth = clientAuth
+		c.ClientCAs = r.ClientCAs()
+		return c, nil
+	}
+	return config
+}
+
+// Watch reloads the files when they change until Close is called. The
+// directories of the files are watched, rather than the files, to follow
+// files replaced by a rename or a symlink swap as done for Kubernetes
+// secrets. Reload failures are logged and the previous certificate is kept.
+func (r *Reloader) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	dirs := make(map[string]bool)
+	for _, file := range r.files() {
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watcher != nil {
+		watcher.Close()
+		return errors.New("certificates are already watched")
+	}
+	r.watcher = watcher
+	r.done = make(chan struct{})
+	go r.watch(watcher, r.done)
+	return nil
+}
+
+func (r *Reloader) watch(watcher *fsnotify.Watcher, done chan struct{}) {
+	defer close(done)
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			// chmod events are frequent and never change the content
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			timer = time.After(reloadDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			zap.L().Warn("watch certificates failed", zap.Error(err))
+		case <-timer:
+			timer = nil
+			r.reload()
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	prev := r.Certificate()
+	if err := r.Reload(); err != nil {
+		zap.L().Warn("reload certificates failed, keeping the previous ones", zap.Error(err))
+		return
+	}
+	cert := r.Certificate()
+	if prev.Leaf.Equal(cert.Leaf) {
+		return
+	}
+	zap.L().Info("certificates reloaded",
+		zap.String("subject", cert.Leaf.Subject.String()),
+		zap.String("serial", cert.Leaf.SerialNumber.String()),
+		zap.Time("not-after", cert.Leaf.NotAfter),
+	)
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+// Close stops watching the files.
+func (r *Reloader) Close() error {
+	r.mu.Lock()
+	watcher, done := r.watcher, r.done
+	r.watcher = nil
+	r.mu.Unlock()
+
+	if watcher == nil {
+		return nil
+	}
+	err := watcher.Close()
+	<-done
+	return err
+}
@@ -0,0 +1,219 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue returns a certificate signed by ca, self-signed if ca is nil.
+func issue(t *testing.T, ca *issuer, serial int64, template x509.Certificate) (*issuer, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parent, signer := &template, key
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &issuer{cert: cert, key: key},
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newCA(t *testing.T, name string) (*issuer, []byte) {
+	ca, certPEM, _ := issue(t, nil, 1, x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	return ca, certPEM
+}
+
+func serverCert(t *testing.T, ca *issuer, serial int64) ([]byte, []byte) {
+	_, certPEM, keyPEM := issue(t, ca, serial, x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return certPEM, keyPEM
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	// write then rename, as done when certificates are rotated
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, _ := newCA(t, "ca")
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	certPEM, keyPEM := serverCert(t, ca, 10)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	r, err := NewReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("NewReloader() error = %v", err)
+	}
+	if err := r.Watch(); err != nil {
+		t.Fatalf("Reloader.Watch() error = %v", err)
+	}
+	defer r.Close()
+
+	// an invalid file keeps the previous certificate
+	writeFile(t, certFile, []byte("garbage"))
+	time.Sleep(3 * reloadDelay)
+	if got := r.Certificate().Leaf.SerialNumber.Int64(); got != 10 {
+		t.Errorf("serial after invalid rotation = %d, want 10", got)
+	}
+
+	certPEM, keyPEM = serverCert(t, ca, 11)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, certFile, certPEM)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Certificate().Leaf.SerialNumber.Int64() != 11 {
+		if time.Now().After(deadline) {
+			t.Fatalf("certificate was not reloaded, serial = %v", r.Certificate().Leaf.SerialNumber)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("Reloader.Close() error = %v", err)
+	}
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	serverCA, serverCAPEM := newCA(t, "server-ca")
+	clientCA, clientCAPEM := newCA(t, "client-ca")
+	certPEM, keyPEM := serverCert(t, serverCA, 10)
+	files := map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM, "ca.crt": clientCAPEM}
+	for name, data := range files {
+		writeFile(t, filepath.Join(dir, name), data)
+	}
+
+	r, err := NewReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("NewReloader() error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(chan *Identity, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id, _ := PeerIdentity(req.TLS)
+		ids <- id
+	})}
+	go srv.Serve(tls.NewListener(listener, r.TLSConfig(tls.RequireAndVerifyClientCert)))
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(serverCAPEM)
+	_, clientPEM, clientKeyPEM := issue(t, clientCA, 20, x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing", Organization: []string{"payments"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		certificates []tls.Certificate
+		wantErr      bool
+		wantName     string
+	}{
+		{
+			name:         "client certificate",
+			certificates: []tls.Certificate{clientCert},
+			wantName:     "billing",
+		},
+		{
+			name:    "no client certificate",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      roots,
+				Certificates: tt.certificates,
+			}}}
+			resp, err := client.Get("https://" + listener.Addr().String())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+
+			id := <-ids
+			if id == nil {
+				t.Fatal("PeerIdentity() = nil, want an identity")
+			}
+			if id.CommonName != tt.wantName || id.Issuer != "CN=client-ca" {
+				t.Errorf("PeerIdentity() = %+v, want common name %s", id, tt.wantName)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Package changefeed is an in-process feed of the changes made to the users
+// and books. The repositories publish an event for each change, watchers
+// subscribe from now on or resume after the token of the last event they
+// received.
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTokenExpired is returned when the events following a resume token
+	// are not kept anymore, or were published by a previous process. The
+	// watcher must read the resources again and subscribe from now on.
+	ErrTokenExpired = errors.New("resume token expired")
+	// ErrInvalidToken is returned for a malformed resume token.
+	ErrInvalidToken = errors.New("invalid resume token")
+	// ErrClosed is returned once the feed is closed.
+	ErrClosed = errors.New("change feed closed")
+)
+
+// Kind is the kind of resource changed.
+type Kind string
+
+// Kinds of resource.
+const (
+	KindUser Kind = "user"
+	KindBook Kind = "book"
+)
+
+// Type is the type of change.
+type Type string
+
+// Types of change.
+const (
+	Created Type = "created"
+	Updated Type = "updated"
+	Deleted Type = "deleted"
+)
+
+// Event is a change of a resource.
+type Event struct {
+	// Token resumes a subscription after this event.
+	Token string    `json:"token"`
+	Kind  Kind      `json:"kind"`
+	Type  Type      `json:"type"`
+	ID    string    `json:"id"`
+	Time  time.Time `json:"time"`
+	// Object is the resource after the change, e.g. a *model.User. It is nil
+	// on deletion.
+	Object interface{} `json:"object,omitempty"`
+}
+
+// Feed keeps the last events published in a ring buffer. A nil *Feed
+// discards the events.
+type Feed struct {
+	// epoch tells the tokens of this feed from those of a previous process,
+	// whose sequence numbers would be mistaken for ours.
+	epoch string
+
+	mu     sync.Mutex
+	events []Event
+	// next is the sequence number of the next event, starting at 1. Event n
+	// is events[(n-1)%len(events)].
+	next uint64
+	// notify is closed when an event is published or the feed is closed.
+	notify chan struct{}
+	closed bool
+}
+
+// New returns a feed keeping the last size events.
+func New(size int) *Feed {
+	return &Feed{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		events: make([]Event, size),
+		next:   1,
+		notify: make(chan struct{}),
+	}
+}
+
+// Publish publishes a change of the resource kind with id.
+func (f *Feed) Publish(kind Kind, typ Type, id string, object interface{}) {
+	if f == nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	f.events[(f.next-1)%uint64(len(f.events))] = Event{
+		Token:  f.token(f.next),
+		Kind:   kind,
+		Type:   typ,
+		ID:     id,
+		Time:   time.Now(),
+		Object: object,
+	}
+	f.next++
+	close(f.notify)
+	f.notify = make(chan struct{})
+}
+
+// Subscribe returns a subscription to the events of kinds, of all kinds if
+// none is given. It starts after the event of token, or from now on if token
+// is empty.
+func (f *Feed) Subscribe(token string, kinds ...Kind) (*Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, ErrClosed
+	}
+
+	s := &Subscription{feed: f, next: f.next, kinds: kinds}
+	if token == "" {
+		return s, nil
+	}
+	epoch, seq, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if epoch != f.epoch {
+		return nil, ErrTokenExpired
+	}
+	if seq >= f.next {
+		return nil, fmt.Errorf("%w: %s is ahead of the feed", ErrInvalidToken, token)
+	}
+	s.next = seq + 1
+	if s.next < f.oldest() {
+		return nil, ErrTokenExpired
+	}
+	return s, nil
+}
+
+// Close ends the subscriptions, e.g. when the server shuts down, so that the
+// streams of events do not hold the shutdown. Events are discarded from then
+// on.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		close(f.notify)
+	}
+}
+
+// oldest returns the sequence number of the oldest event kept.
+func (f *Feed) oldest() uint64 {
+	if size := uint64(len(f.events)); f.next > size {
+		return f.next - size
+	}
+	return 1
+}
+
+func (f *Feed) token(seq uint64) string {
+	return f.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+func parseToken(token string) (string, uint64, error) {
+	i := strings.LastIndexByte(token, '-')
+	if i <= 0 {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidToken, token)
+	}
+	seq, err := strconv.ParseUint(token[i+1:], 10, 64)
+	if err != nil || seq == 0 {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidToken, token)
+	}
+	return token[:i], seq, nil
+}
+
+// Subscription reads the events of a feed in order.
+type Subscription struct {
+	feed  *Feed
+	next  uint64
+	kinds []Kind
+}
+
+// Next waits for the next event. It returns ErrTokenExpired if the
+// subscriber fell so far behind that the event is not kept anymore,
+// ErrClosed once the feed is closed and ctx.Err() if ctx is done first.
+func (s *Subscription) Next(ctx context.Context) (Event, error) {
+	f := s.feed
+	for {
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			return Event{}, ErrClosed
+		}
+		if s.next < f.oldest() {
+			f.mu.Unlock()
+			return Event{}, ErrTokenExpired
+		}
+		if s.next < f.next {
+			event := f.events[(s.next-1)%uint64(len(f.events))]
+			s.next++
+			f.mu.Unlock()
+			if s.matches(event) {
+				return event, nil
+			}
+			continue
+		}
+		notify := f.notify
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+func (s *Subscription) matches(event Event) bool {
+	if len(s.kinds) == 0 {
+		return true
+	}
+	for _, kind := range s.kinds {
+		if kind == event.Kind {
+			return true
+		}
+	}
+	return false
+}
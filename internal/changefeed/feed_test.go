@@ -0,0 +1,174 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// next reads the next event, failing the test if none is published soon.
+func next(t *testing.T, s *Subscription) Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	event, err := s.Next(ctx)
+	if err != nil {
+		t.Fatalf("Subscription.Next() error = %v", err)
+	}
+	return event
+}
+
+func TestFeed_Subscribe(t *testing.T) {
+	f := New(16)
+	f.Publish(KindUser, Created, "1", nil)
+	first := f.events[0].Token
+	f.Publish(KindBook, Created, "1", nil)
+	f.Publish(KindUser, Updated, "1", nil)
+
+	tests := []struct {
+		name      string
+		token     string
+		kinds     []Kind
+		wantTypes []Type
+		wantErr   error
+	}{
+		{
+			name:      "from now on",
+			wantTypes: []Type{Deleted},
+		},
+		{
+			name:      "resume",
+			token:     first,
+			wantTypes: []Type{Created, Updated, Deleted},
+		},
+		{
+			name:      "resume with a filter",
+			token:     first,
+			kinds:     []Kind{KindUser},
+			wantTypes: []Type{Updated, Deleted},
+		},
+		{
+			name:    "token of a previous process",
+			token:   "abc-1",
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "token ahead of the feed",
+			token:   f.token(10),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "malformed token",
+			token:   "abc",
+			wantErr: ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := f.Subscribe(tt.token, tt.kinds...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Feed.Subscribe() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			// published after the subscription, seen by all of them
+			go f.Publish(KindUser, Deleted, "1", nil)
+			for _, want := range tt.wantTypes {
+				if event := next(t, s); event.Type != want {
+					t.Errorf("Subscription.Next() = %v, want a %v event", event, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFeed_Expired(t *testing.T) {
+	f := New(2)
+	f.Publish(KindUser, Created, "1", nil)
+	token := f.events[0].Token
+	s, err := f.Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		f.Publish(KindUser, Updated, "1", nil)
+	}
+	// the event following token is dropped from the ring
+	if _, err := f.Subscribe(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Feed.Subscribe() error = %v, want %v", err, ErrTokenExpired)
+	}
+	// so is the next event of the subscriber
+	if _, err := s.Next(context.Background()); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Subscription.Next() error = %v, want %v", err, ErrTokenExpired)
+	}
+}
+
+func TestSubscription_Next(t *testing.T) {
+	f := New(8)
+	s, err := f.Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Next(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Subscription.Next() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	time.AfterFunc(10*time.Millisecond, func() { f.Publish(KindBook, Created, "2", "book") })
+	event := next(t, s)
+	if event.Kind != KindBook || event.Type != Created || event.ID != "2" || event.Object != "book" {
+		t.Errorf("Subscription.Next() = %+v", event)
+	}
+
+	// the token of an event resumes after it
+	f.Publish(KindBook, Deleted, "2", nil)
+	resumed, err := f.Subscribe(event.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := next(t, resumed); event.Type != Deleted {
+		t.Errorf("Subscription.Next() after resume = %+v, want the deletion", event)
+	}
+}
+
+func TestFeed_Close(t *testing.T) {
+	f := New(2)
+	s, err := f.Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.AfterFunc(10*time.Millisecond, f.Close)
+	if _, err := s.Next(context.Background()); err != ErrClosed {
+		t.Errorf("Subscription.Next() error = %v, want %v", err, ErrClosed)
+	}
+	if _, err := f.Subscribe(""); err != ErrClosed {
+		t.Errorf("Feed.Subscribe() error = %v, want %v", err, ErrClosed)
+	}
+	f.Publish(KindUser, Created, "1", nil)
+	f.Close()
+}
+
+func TestStreamContext(t *testing.T) {
+	ctx, cancel := StreamContext(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("StreamContext() without write timeout has a deadline")
+	}
+
+	ctx, cancel = StreamContext(WithWriteTimeout(context.Background(), 10*time.Second))
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > 9*time.Second {
+		t.Errorf("StreamContext() deadline = %v, want before the write timeout", deadline)
+	}
+
+	var f *Feed
+	f.Publish(KindUser, Created, "1", nil)
+}
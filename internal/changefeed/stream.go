@@ -0,0 +1,30 @@
+package changefeed
+
+import (
+	"context"
+	"time"
+)
+
+type writeTimeoutKey struct{}
+
+// WithWriteTimeout returns a copy of ctx telling the streams of the feed that
+// their response is cut after timeout, as done by the WriteTimeout of a
+// http.Server for HTTP/1.1 and TLS HTTP/2 requests.
+func WithWriteTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if timeout <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, writeTimeoutKey{}, timeout)
+}
+
+// StreamContext returns a copy of ctx for a stream of events. If ctx carries
+// a write timeout, the copy is done shortly before the timeout so that the
+// stream can end cleanly and the client resume it, rather than have its
+// response cut.
+func StreamContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout, ok := ctx.Value(writeTimeoutKey{}).(time.Duration)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout-timeout/10)
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+
+	"go-template/internal/config"
+	"go-template/internal/errno"
+	"go-template/internal/log"
+)
+
+// Exit codes returned by the commands.
+const (
+	// ExitOK means the command succeeded.
+	ExitOK = 0
+	// ExitError means the command failed at runtime, e.g. the database is
+	// unreachable.
+	ExitError = 1
+	// ExitUsage means the command line is invalid.
+	ExitUsage = 2
+	// ExitConfig means the configuration could not be loaded or is invalid.
+	ExitConfig = 3
+)
+
+const programName = "go-template"
+
+// Command is a command of the program. A command either runs or dispatches
+// to one of its subcommands.
+type Command struct {
+	// Name is the word that selects the command.
+	Name string
+	// Short is the one-line description shown in the parent's help.
+	Short string
+	// Long is the description shown in the command's help.
+	Long string
+	// Flags are the flags of the command.
+	Flags *pflag.FlagSet
+	// Run runs the command with the remaining arguments and returns the
+	// exit code. A command without Run prints its help.
+	Run func(args []string) int
+
+	parent      *Command
+	subcommands []*Command
+}
+
+// AddCommand adds subcommands to c.
+func (c *Command) AddCommand(cmds ...*Command) {
+	for _, sub := range cmds {
+		sub.parent = c
+		c.subcommands = append(c.subcommands, sub)
+	}
+}
+
+func (c *Command) path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.path() + " " + c.Name
+}
+
+func (c *Command) flags() *pflag.FlagSet {
+	if c.Flags == nil {
+		c.Flags = pflag.NewFlagSet(c.Name, pflag.ContinueOnError)
+	}
+	return c.Flags
+}
+
+// Execute runs c, or the subcommand selected by the first argument, and
+// returns the exit code.
+func (c *Command) Execute(args []string) int {
+	if len(args) > 0 {
+		for _, sub := range c.subcommands {
+			if sub.Name == args[0] {
+				return sub.Execute(args[1:])
+			}
+		}
+		if args[0] == "help" {
+			c.usage(os.Stdout)
+			return ExitOK
+		}
+	}
+
+	fs := c.flags()
+	fs.SetOutput(ioutil.Discard)
+	switch err := fs.Parse(args); {
+	case err == pflag.ErrHelp:
+		c.usage(os.Stdout)
+		return ExitOK
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error: %s\n\n", err.Error())
+		c.usage(os.Stderr)
+		return ExitUsage
+	}
+
+	if c.Run == nil {
+		if fs.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n\n", fs.Arg(0))
+		}
+		c.usage(os.Stderr)
+		return ExitUsage
+	}
+	return c.Run(fs.Args())
+}
+
+func (c *Command) usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage:\n  %s", c.path())
+	if len(c.subcommands) > 0 {
+		fmt.Fprint(w, " <command>")
+	}
+	fmt.Fprint(w, " [flags]\n")
+	if c.Long != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(c.Long))
+	}
+	if len(c.subcommands) > 0 {
+		fmt.Fprint(w, "\nCommands:\n")
+		for _, sub := range c.subcommands {
+			fmt.Fprintf(w, "  %-10s %s\n", sub.Name, sub.Short)
+		}
+	}
+	if usages := c.flags().FlagUsages(); usages != "" {
+		fmt.Fprintf(w, "\nFlags:\n%s", usages)
+	}
+}
+
+// configOptions are the flags shared by the commands that load the
+// configuration.
+type configOptions struct {
+	file    string
+	profile string
+}
+
+func (o *configOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.file, "conf", "configs/config.yaml", "configuration file")
+	fs.StringVar(&o.profile, "profile", os.Getenv(config.ProfileEnv),
+		"configuration profile overlaid on the configuration file, e.g. dev, staging or prod (env "+config.ProfileEnv+")")
+}
+
+func (o *configOptions) loader() *config.Loader {
+	return config.NewLoader(o.file, o.profile)
+}
+
+// load loads the configuration and sets up the global logger. It reports
+// failures on stderr, the returned code is ExitOK on success.
+func (o *configOptions) load() (*config.Loader, *config.Config, int) {
+	loader := o.loader()
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return nil, nil, ExitConfig
+	}
+
+	// configure logging
+	logger, err := log.New(cfg.Logger.Level, cfg.Logger.OutputPaths, cfg.Logger.ErrorOutputPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create logger: %s\n", err.Error())
+		return nil, nil, ExitConfig
+	}
+	zap.ReplaceGlobals(logger)
+	errno.CaptureStacks(cfg.Logger.ErrorStacks)
+
+	return loader, cfg, ExitOK
+}
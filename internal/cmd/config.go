@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/multierr"
+
+	"go-template/internal/config"
+)
+
+func newConfigCommand() *Command {
+	c := &Command{
+		Name:  "config",
+		Short: "Check or print the configuration",
+		Long:  "Inspects the merged configuration without starting the server.",
+	}
+	c.AddCommand(newConfigCheckCommand(), newConfigPrintCommand())
+	return c
+}
+
+func newConfigCheckCommand() *Command {
+	var opts configOptions
+	c := &Command{
+		Name:  "check",
+		Short: "Validate the configuration",
+		Long: `Validates the merged configuration and prints every problem found. Exits with
+code 3 if the configuration is invalid, so deploy pipelines can reject it.`,
+		Run: func(args []string) int {
+			return runConfigCheck(opts.loader())
+		},
+	}
+	opts.addFlags(c.flags())
+	return c
+}
+
+func newConfigPrintCommand() *Command {
+	var (
+		opts   configOptions
+		format string
+	)
+	c := &Command{
+		Name:  "print",
+		Short: "Print the effective configuration",
+		Long: `Prints the merged configuration with secrets masked, together with the source
+of each value: default, file:<path> or env:<variable>.`,
+		Run: func(args []string) int {
+			return runConfigPrint(opts.loader(), format)
+		},
+	}
+	opts.addFlags(c.flags())
+	c.flags().StringVar(&format, "format", "yaml", "output format: yaml or json")
+	return c
+}
+
+// runConfigCheck reports the result of loading the configuration.
+func runConfigCheck(loader *config.Loader) int {
+	files := strings.Join(loader.Files(), ", ")
+	if _, err := loader.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: invalid configuration\n", files)
+		for _, e := range multierr.Errors(err) {
+			fmt.Fprintf(os.Stderr, "  - %s\n", e.Error())
+		}
+		return ExitConfig
+	}
+	fmt.Printf("%s: configuration OK\n", files)
+	return ExitOK
+}
+
+// runConfigPrint prints the effective configuration with secrets masked.
+func runConfigPrint(loader *config.Loader, format string) int {
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return ExitConfig
+	}
+	out, err := cfg.Dump().Marshal(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return ExitUsage
+	}
+	fmt.Println(string(out))
+	return ExitOK
+}
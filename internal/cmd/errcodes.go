@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"go-template/internal/errno"
+)
+
+func newErrcodesCommand() *Command {
+	var format string
+	c := &Command{
+		Name:  "errcodes",
+		Short: "Print the catalog of error codes",
+		Long: `Prints every registered error code with its category, HTTP status, gRPC code
+and default message, as a Markdown table or as JSON for clients to generate
+their error handling from.`,
+		Run: func(args []string) int {
+			return runErrcodes(format)
+		},
+	}
+	c.flags().StringVar(&format, "format", "markdown", "output format: markdown or json")
+	return c
+}
+
+func runErrcodes(format string) int {
+	out, err := errno.MarshalCatalog(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return ExitUsage
+	}
+	fmt.Print(string(out))
+	if format == "json" {
+		fmt.Println()
+	}
+	return ExitOK
+}
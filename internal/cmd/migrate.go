@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"go-template/internal/database"
+	"go-template/internal/migrate"
+)
+
+// migrateOptions are the flags shared by the migrate commands.
+type migrateOptions struct {
+	configOptions
+	dir string
+}
+
+func (o *migrateOptions) addFlags(c *Command) {
+	o.configOptions.addFlags(c.flags())
+	c.flags().StringVar(&o.dir, "dir", "migrations", "directory of the migration files")
+}
+
+// open loads the configuration and the migrations and connects to the
+// database.
+func (o *migrateOptions) open() (*migrate.Migrator, *sqlx.DB, int) {
+	_, cfg, code := o.load()
+	if code != ExitOK {
+		return nil, nil, code
+	}
+	migrations, err := migrate.Load(o.dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load migrations: %s\n", err.Error())
+		return nil, nil, ExitError
+	}
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: connect to database failed: %s\n", err.Error())
+		return nil, nil, ExitError
+	}
+	return migrate.New(db, migrations), db, ExitOK
+}
+
+func newMigrateCommand() *Command {
+	var opts migrateOptions
+	c := &Command{
+		Name:  "migrate",
+		Short: "Apply database migrations",
+		Long: `Applies the pending migrations in the migrations directory, as "migrate up"
+does. Migration files are named <version>_<name>.up.sql and
+<version>_<name>.down.sql, applied versions are recorded in the
+schema_migrations table.`,
+		Run: func(args []string) int {
+			return runMigrateUp(&opts)
+		},
+	}
+	opts.addFlags(c)
+	c.AddCommand(newMigrateUpCommand(), newMigrateDownCommand(), newMigrateStatusCommand())
+	return c
+}
+
+func newMigrateUpCommand() *Command {
+	var opts migrateOptions
+	c := &Command{
+		Name:  "up",
+		Short: "Apply the pending migrations",
+		Long:  "Applies the pending migrations in version order.",
+		Run: func(args []string) int {
+			return runMigrateUp(&opts)
+		},
+	}
+	opts.addFlags(c)
+	return c
+}
+
+func newMigrateDownCommand() *Command {
+	var (
+		opts  migrateOptions
+		steps int
+	)
+	c := &Command{
+		Name:  "down",
+		Short: "Roll back applied migrations",
+		Long:  "Rolls back the last applied migrations, one by default.",
+		Run: func(args []string) int {
+			if steps < 1 {
+				fmt.Fprintln(os.Stderr, "Error: --steps must be at least 1")
+				return ExitUsage
+			}
+			m, db, code := opts.open()
+			if code != ExitOK {
+				return code
+			}
+			defer db.Close()
+			defer zap.L().Sync()
+
+			done, err := m.Down(context.Background(), steps)
+			for _, migration := range done {
+				fmt.Printf("rolled back %d_%s\n", migration.Version, migration.Name)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+				return ExitError
+			}
+			return ExitOK
+		},
+	}
+	opts.addFlags(c)
+	c.flags().IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	return c
+}
+
+func newMigrateStatusCommand() *Command {
+	var opts migrateOptions
+	c := &Command{
+		Name:  "status",
+		Short: "Show the state of the migrations",
+		Long:  "Lists the migrations and whether they are applied.",
+		Run: func(args []string) int {
+			m, db, code := opts.open()
+			if code != ExitOK {
+				return code
+			}
+			defer db.Close()
+			defer zap.L().Sync()
+
+			status, err := m.Status(context.Background())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+				return ExitError
+			}
+			for _, s := range status {
+				state := "pending"
+				if s.Applied {
+					state = "applied at " + s.AppliedAt
+				}
+				fmt.Printf("%d_%s\t%s\n", s.Version, s.Name, state)
+			}
+			return ExitOK
+		},
+	}
+	opts.addFlags(c)
+	return c
+}
+
+func runMigrateUp(opts *migrateOptions) int {
+	m, db, code := opts.open()
+	if code != ExitOK {
+		return code
+	}
+	defer db.Close()
+	defer zap.L().Sync()
+
+	done, err := m.Up(context.Background())
+	for _, migration := range done {
+		fmt.Printf("applied %d_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return ExitError
+	}
+	if len(done) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	return ExitOK
+}
@@ -0,0 +1,43 @@
+package cmd
+
+// NewRootCommand returns the command line of the program. Without a command
+// the server is started, --version and --check-config are kept for
+// compatibility with previous versions.
+func NewRootCommand() *Command {
+	var (
+		opts        configOptions
+		showVersion bool
+		asJSON      bool
+		checkConfig bool
+	)
+	root := &Command{
+		Name: programName,
+		Long: `go-template is a HTTP service template.
+
+Without a command it starts the server, as "serve" does.`,
+	}
+	fs := root.flags()
+	opts.addFlags(fs)
+	fs.BoolVarP(&showVersion, "version", "v", false, "print the version and exit, as \"version\" does")
+	fs.BoolVar(&asJSON, "json", false, "print the build information as JSON with --version")
+	fs.BoolVar(&checkConfig, "check-config", false, "validate the configuration and exit, as \"config check\" does")
+	root.Run = func(args []string) int {
+		switch {
+		case showVersion:
+			return runVersion(asJSON)
+		case checkConfig:
+			return runConfigCheck(opts.loader())
+		}
+		return runServe(&opts)
+	}
+
+	root.AddCommand(
+		newServeCommand(),
+		newMigrateCommand(),
+		newSeedCommand(),
+		newConfigCommand(),
+		newErrcodesCommand(),
+		newVersionCommand(),
+	)
+	return root
+}
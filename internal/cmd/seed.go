@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"go-template/internal/database"
+	"go-template/internal/migrate"
+)
+
+func newSeedCommand() *Command {
+	var (
+		opts  configOptions
+		dir   string
+		force bool
+	)
+	c := &Command{
+		Name:  "seed",
+		Short: "Load sample data into the database",
+		Long: `Executes the *.sql files of the seed directory in lexical order. Seeding is
+refused with the prod profile unless --force is given.`,
+		Run: func(args []string) int {
+			if opts.profile == "prod" && !force {
+				fmt.Fprintln(os.Stderr, "Error: refusing to seed with the prod profile, use --force")
+				return ExitUsage
+			}
+			_, cfg, code := opts.load()
+			if code != ExitOK {
+				return code
+			}
+			defer zap.L().Sync()
+
+			db, err := database.Connect(cfg.Database)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: connect to database failed: %s\n", err.Error())
+				return ExitError
+			}
+			defer db.Close()
+
+			done, err := migrate.Seed(context.Background(), db, dir)
+			for _, file := range done {
+				fmt.Printf("seeded %s\n", file)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+				return ExitError
+			}
+			return ExitOK
+		},
+	}
+	opts.addFlags(c.flags())
+	c.flags().StringVar(&dir, "dir", "seeds", "directory of the seed files")
+	c.flags().BoolVar(&force, "force", false, "seed even with the prod profile")
+	return c
+}
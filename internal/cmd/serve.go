@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"go.uber.org/zap"
+
+	"go-template/internal/config"
+	"go-template/internal/log"
+	"go-template/internal/server"
+	"go-template/internal/signals"
+)
+
+func newServeCommand() *Command {
+	var opts configOptions
+	c := &Command{
+		Name:  "serve",
+		Short: "Start the HTTP server",
+		Long: `Starts the HTTP server, the gRPC server and the metrics server, and stops
+them gracefully on SIGINT or SIGTERM. gRPC shares the HTTP port unless
+grpc.port is set. Changes to the configuration files are applied live when
+possible.`,
+		Run: func(args []string) int {
+			return runServe(&opts)
+		},
+	}
+	opts.addFlags(c.flags())
+	return c
+}
+
+func runServe(opts *configOptions) int {
+	loader, cfg, code := opts.load()
+	if code != ExitOK {
+		return code
+	}
+	logger := zap.L()
+	defer logger.Sync()
+
+	// log version and port
+	logger.Info("Starting server",
+		zap.Any("config", cfg.Dump()),
+	)
+
+	// apply configuration changes without restart
+	watcher := config.NewWatcher(loader, cfg)
+	watcher.OnLoggerChange(reloadLogger)
+
+	// start HTTP and gRPC server
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		logger.Error("failed to create server", zap.Error(err))
+		return ExitError
+	}
+	srv.Watch(watcher)
+	watcher.Start()
+	stopCh := signals.SetupSignalHandler()
+	if err := srv.Run(stopCh); err != nil {
+		logger.Error("server stopped with errors", zap.Error(err))
+		return ExitError
+	}
+	return ExitOK
+}
+
+// reloadLogger applies the new log level, changing the output paths requires a
+// restart.
+func reloadLogger(old, new config.Logger) {
+	log.SetLevel(new.Level)
+	zap.L().Info("log level reloaded", zap.String("level", new.Level))
+	for _, field := range config.Diff(old, new) {
+		if field != "level" {
+			zap.L().Warn("configuration change requires a restart", zap.String("field", "logger."+field))
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go-template/internal/version"
+)
+
+func newVersionCommand() *Command {
+	var asJSON bool
+	c := &Command{
+		Name:  "version",
+		Short: "Print the version",
+		Long: `Prints the version of the program. With --json, prints the full build
+information: commit, build date, dirty flag, Go version and module versions.`,
+		Run: func(args []string) int {
+			return runVersion(asJSON)
+		},
+	}
+	c.flags().BoolVar(&asJSON, "json", false, "print the build information as JSON")
+	return c
+}
+
+func runVersion(asJSON bool) int {
+	info := version.Get()
+	if !asJSON {
+		fmt.Println(info.Version)
+		return ExitOK
+	}
+
+	out, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		return ExitError
+	}
+	fmt.Println(string(out))
+	return ExitOK
+}
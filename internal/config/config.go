@@ -1,8 +1,12 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -11,40 +15,218 @@ import (
 // Config represents program configuration
 type Config struct {
 	HTTP     HTTP     `mapstructure:"http"`
+	GRPC     GRPC     `mapstructure:"grpc"`
 	Redis    Redis    `mapstructure:"redis"`
 	Logger   Logger   `mapstructure:"logger"`
 	Database Database `mapstructure:"database"`
+
+	// sources maps each key to the layer its value came from, see Dump.
+	sources map[string]string
+	// resolved holds the keys whose value was resolved from a secret
+	// reference, Dump masks them like the secret fields.
+	resolved map[string]bool
+	// profile is the profile the configuration was loaded with.
+	profile string
 }
 
+// EnvPrefix is the prefix of the environment variables that override
+// configuration keys. Nested keys are joined with underscores and dashes are
+// replaced, e.g. APP_DATABASE_PASSWORD overrides database.password and
+// APP_HTTP_PORT_METRICS overrides http.port-metrics.
+const EnvPrefix = "APP"
+
+// ProfileEnv is the environment variable that selects the profile when it is
+// not given on the command line.
+const ProfileEnv = EnvPrefix + "_PROFILE"
+
+// resolveTimeout bounds the time spent resolving secret references.
+const resolveTimeout = 10 * time.Second
+
 // New returns Config object that reads configurations from a file.
-func New(configFile string) *Config {
+// An error is returned if the file can not be read or decoded, or if the
+// resulting configuration fails validation.
+func New(configFile, profile string) (*Config, error) {
+	return NewLoader(configFile, profile).Load()
+}
+
+// Loader loads the configuration in layers: defaults, the base file, the
+// overlay of the selected profile and finally environment variables. Secret
+// references in the result are then resolved, see Resolver.
+type Loader struct {
+	file      string
+	profile   string
+	resolvers map[string]Resolver
+}
+
+// NewLoader returns a Loader for the base file and profile. The overlay of
+// profile "prod" for "configs/config.yaml" is "configs/config.prod.yaml".
+// An empty profile loads the base file only.
+func NewLoader(configFile, profile string) *Loader {
+	return &Loader{
+		file:    configFile,
+		profile: profile,
+		resolvers: map[string]Resolver{
+			"file": FileResolver,
+			"env":  EnvResolver,
+		},
+	}
+}
+
+// RegisterResolver registers the resolver of secret references with scheme,
+// replacing any resolver previously registered for it.
+func (l *Loader) RegisterResolver(scheme string, r Resolver) {
+	l.resolvers[scheme] = r
+}
+
+// Files returns the configuration files in the order they are merged.
+func (l *Loader) Files() []string {
+	files := []string{l.file}
+	if l.profile != "" {
+		ext := filepath.Ext(l.file)
+		files = append(files, strings.TrimSuffix(l.file, ext)+"."+l.profile+ext)
+	}
+	return files
+}
+
+// Load reads and validates the configuration.
+func (l *Loader) Load() (*Config, error) {
+	v := viper.New()
 
 	// Set default configurations
-	setDefaults()
+	setDefaults(v)
 
-	// Set configuration file
-	viper.SetConfigFile(configFile)
+	// Read the base file and merge the profile overlay into it
+	files := make(map[string]*viper.Viper)
+	for i, file := range l.Files() {
+		v.SetConfigFile(file)
+		read := v.MergeInConfig
+		if i == 0 {
+			read = v.ReadInConfig
+		}
+		if err := read(); err != nil {
+			return nil, fmt.Errorf("failed to read configuration %s: %w", file, err)
+		}
 
-	// Automatically refresh environment variables
-	viper.AutomaticEnv()
+		// keep each layer apart to tell where a value came from
+		files[file] = viper.New()
+		files[file].SetConfigFile(file)
+		if err := files[file].ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read configuration %s: %w", file, err)
+		}
+	}
 
-	// Read configuration
-	if err := viper.ReadInConfig(); err != nil {
-		fmt.Println("failed to read configuration: ", err.Error())
-		os.Exit(1)
+	// Bind every key to its environment variable, Unmarshal only sees keys
+	// that viper knows about.
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	keys := configKeys(reflect.TypeOf(Config{}), "")
+	for _, key := range keys {
+		if err := v.BindEnv(key); err != nil {
+			return nil, err
+		}
 	}
 
-	var config *Config
-	if err := viper.Unmarshal(&config); err != nil {
-		fmt.Println("HTTP server config unmarshal failed", err.Error())
+	config := &Config{}
+	if err := v.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
 	}
+	config.sources = l.sources(keys, files)
+	config.profile = l.profile
 
-	return config
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	if err := resolveSecrets(ctx, config, l.resolvers); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
 }
 
-func setDefaults() {
-	// Set default database configuration
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
 
+// envName returns the environment variable bound to key.
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// configKeys returns the mapstructure keys of t and its nested structs.
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("mapstructure")
+		if tag == "" {
+			continue
+		}
+		if f.Type.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(f.Type, prefix+tag+".")...)
+			continue
+		}
+		keys = append(keys, prefix+tag)
+	}
+	return keys
+}
+
+// sources tells for each key whether its value came from an environment
+// variable, one of the files or the defaults. Later layers win.
+func (l *Loader) sources(keys []string, files map[string]*viper.Viper) map[string]string {
+	sources := make(map[string]string, len(keys))
+	layers := l.Files()
+	for _, key := range keys {
+		sources[key] = SourceDefault
+		if _, ok := os.LookupEnv(envName(key)); ok {
+			sources[key] = SourceEnv + ":" + envName(key)
+			continue
+		}
+		for i := len(layers) - 1; i >= 0; i-- {
+			if files[layers[i]].IsSet(key) {
+				sources[key] = SourceFile + ":" + layers[i]
+				break
+			}
+		}
+	}
+	return sources
+}
+
+func setDefaults(v *viper.Viper) {
+	// Set default http configuration
+	v.SetDefault("http.port", "8000")
+	v.SetDefault("http.port-metrics", 9898)
+	v.SetDefault("http.http-server-timeout", 30*time.Second)
+	v.SetDefault("http.http-server-shutdown-timeout", 5*time.Second)
+	v.SetDefault("http.http-server-shutdown-delay", time.Duration(0))
+	v.SetDefault("http.debug", false)
+	v.SetDefault("http.errors.format", "envelope")
+	v.SetDefault("http.errors.type-base", "urn:go-template:errno:")
+
+	// Set default grpc configuration
+	v.SetDefault("grpc.port", 0)
+	v.SetDefault("grpc.gateway.enabled", false)
+	v.SetDefault("grpc.gateway.prefix", "/api")
+
+	// Set default redis configuration
+	v.SetDefault("redis.MaxIdle", 10)
+	v.SetDefault("redis.IdleTimeout", 30*time.Second)
+	v.SetDefault("redis.ConnectTimeout", 5*time.Second)
+	v.SetDefault("redis.ReadTimeout", 5*time.Second)
+	v.SetDefault("redis.WriteTimeout", 5*time.Second)
+	v.SetDefault("redis.Port", "6379")
+
+	// Set default logger configuration
+	v.SetDefault("logger.level", "info")
+	v.SetDefault("logger.output-paths", []string{"stderr"})
+	v.SetDefault("logger.error-output-paths", []string{"stderr"})
+	v.SetDefault("logger.error-stacks", false)
+
+	// Set default database configuration
+	v.SetDefault("database.port", "3306")
+	v.SetDefault("database.timeout", 30*time.Second)
+	v.SetDefault("database.read-timeout", 5*time.Second)
+	v.SetDefault("database.write-timeout", 5*time.Second)
 }
 
 // HTTP is http configuration
@@ -53,6 +235,55 @@ type HTTP struct {
 	PortMetrics               int           `mapstructure:"port-metrics"`
 	HTTPServerTimeout         time.Duration `mapstructure:"http-server-timeout"`
 	HTTPServerShutdownTimeout time.Duration `mapstructure:"http-server-shutdown-timeout"`
+	HTTPServerShutdownDelay   time.Duration `mapstructure:"http-server-shutdown-delay"`
+	TLS                       TLS           `mapstructure:"tls"`
+	// Debug renders the detail of the errors in the responses, which may
+	// leak internals such as database errors.
+	Debug  bool   `mapstructure:"debug"`
+	Errors Errors `mapstructure:"errors"`
+}
+
+// Errors is the configuration of the error responses. Format is "envelope",
+// the {code,message} body, or "problem", RFC 7807 application/problem+json
+// documents, which clients also get by accepting them. The type of the
+// problems is TypeBase followed by the errno code.
+type Errors struct {
+	Format   string `mapstructure:"format"`
+	TypeBase string `mapstructure:"type-base"`
+}
+
+// TLS is the TLS configuration of the HTTP server. TLS is enabled when a
+// certificate is given, the files are reloaded when they change on disk.
+//
+// ClientAuth is one of "none", "request", "require", "verify-if-given" and
+// "require-and-verify". It defaults to "require-and-verify" when a client CA
+// is given, enabling mutual TLS, and to "none" otherwise.
+type TLS struct {
+	CertFile     string `mapstructure:"cert-file"`
+	KeyFile      string `mapstructure:"key-file"`
+	ClientCAFile string `mapstructure:"client-ca-file"`
+	ClientAuth   string `mapstructure:"client-auth"`
+}
+
+// Enabled reports whether the HTTP server serves TLS.
+func (c TLS) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// GRPC is grpc configuration. The gRPC server shares the HTTP port unless a
+// port of its own is given.
+type GRPC struct {
+	Port        int     `mapstructure:"port"`
+	ServiceName string  `mapstructure:"service-name"`
+	Gateway     Gateway `mapstructure:"gateway"`
+}
+
+// Gateway is the configuration of the REST gateway of the gRPC services. It
+// is served by the HTTP server under Prefix, e.g. GET /api/v1/users/1 calls
+// UserService.GetUser.
+type Gateway struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Prefix  string `mapstructure:"prefix"`
 }
 
 // Redis is redis configuration
@@ -65,7 +296,7 @@ type Redis struct {
 	Host           string        `mapstructure:"Host"`
 	Port           string        `mapstructure:"Port"`
 	Username       string        `mapstructure:"Username"`
-	Password       string        `mapstructure:"Password"`
+	Password       string        `mapstructure:"Password" secret:"true"`
 	DB             int           `mapstructure:"DB"`
 }
 
@@ -74,12 +305,15 @@ type Logger struct {
 	Level            string   `mapstructure:"level"`
 	OutputPaths      []string `mapstructure:"output-paths"`
 	ErrorOutputPaths []string `mapstructure:"error-output-paths"`
+	// ErrorStacks records where errno errors are wrapped, the stacks are
+	// logged with the failed requests.
+	ErrorStacks bool `mapstructure:"error-stacks"`
 }
 
 // Database is mysql configuration
 type Database struct {
 	User         string        `mapstructure:"user"`
-	Password     string        `mapstructure:"password"`
+	Password     string        `mapstructure:"password" secret:"true"`
 	DBName       string        `mapstructure:"dbname"`
 	Host         string        `mapstructure:"host"`
 	Port         string        `mapstructure:"port"`
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const baseConfig = `
+http:
+  port: 8000
+redis:
+  Host: 127.0.0.1
+  Password: base
+logger:
+  level: debug
+database:
+  user: root
+  password: base
+  host: 127.0.0.1
+  dbname: test
+`
+
+const prodConfig = `
+http:
+  http-server-timeout: 10s
+redis:
+  Password: env:TEST_REDIS_PASS
+logger:
+  level: warn
+database:
+  password: env:TEST_DB_PASS
+`
+
+func writeConfigs(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoader_Load(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"config.yaml":      baseConfig,
+		"config.prod.yaml": prodConfig,
+	})
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+
+	tests := []struct {
+		name    string
+		profile string
+		env     map[string]string
+		check   func(c *Config) bool
+		wantErr bool
+	}{
+		{
+			name:    "base with defaults",
+			profile: "",
+			check: func(c *Config) bool {
+				return c.Logger.Level == "debug" && c.HTTP.HTTPServerTimeout == 30*time.Second &&
+					c.Database.Port == "3306"
+			},
+		},
+		{
+			name:    "profile overlay",
+			profile: "prod",
+			env: map[string]string{
+				"TEST_REDIS_PASS": "redis-secret",
+				"TEST_DB_PASS":    "secret",
+			},
+			check: func(c *Config) bool {
+				return c.Logger.Level == "warn" && c.HTTP.HTTPServerTimeout == 10*time.Second &&
+					c.HTTP.Port == "8000" && c.Redis.Password == "redis-secret" && c.Database.Password == "secret"
+			},
+		},
+		{
+			name:    "env overrides nested keys",
+			profile: "prod",
+			env: map[string]string{
+				"APP_DATABASE_PASSWORD":         "secret",
+				"APP_HTTP_PORT_METRICS":         "9999",
+				"APP_LOGGER_ERROR_OUTPUT_PATHS": "stderr,logs/error.log",
+				"APP_HTTP_HTTP_SERVER_TIMEOUT":  "20s",
+				"APP_REDIS_PASSWORD":            "redis-secret",
+			},
+			check: func(c *Config) bool {
+				return c.Database.Password == "secret" && c.HTTP.PortMetrics == 9999 &&
+					reflect.DeepEqual(c.Logger.ErrorOutputPaths, []string{"stderr", "logs/error.log"}) &&
+					c.HTTP.HTTPServerTimeout == 20*time.Second && c.Redis.Password == "redis-secret"
+			},
+		},
+		{
+			name:    "missing profile overlay",
+			profile: "staging",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+			got, err := NewLoader(file, tt.profile).Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Loader.Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(got) {
+				t.Errorf("Loader.Load() = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Sources of configuration values reported by Dump.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// redactedValue replaces the values of fields tagged with `secret:"true"`
+// and of the fields resolved from secret references.
+const redactedValue = "******"
+
+// Dump is the effective configuration with secrets masked, along with the
+// source of each value keyed by its dotted path, e.g. "database.password".
+type Dump struct {
+	Config  map[string]interface{} `json:"config" yaml:"config"`
+	Sources map[string]string      `json:"sources" yaml:"sources"`
+}
+
+// Dump returns the redacted configuration. It is safe to log.
+func (c *Config) Dump() *Dump {
+	return &Dump{
+		Config:  redact(reflect.ValueOf(*c), "", c.resolved),
+		Sources: c.sources,
+	}
+}
+
+// Marshal encodes the dump in format, either "yaml" or "json".
+func (d *Dump) Marshal(format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		return yaml.Marshal(d)
+	case "json":
+		return json.MarshalIndent(d, "", "  ")
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// redact converts a configuration struct to a map keyed by mapstructure
+// names, masking the non-empty values of secret fields and of the resolved
+// keys. Durations are rendered as in the configuration file.
+func redact(v reflect.Value, prefix string, resolved map[string]bool) map[string]interface{} {
+	m := make(map[string]interface{})
+	for i := 0; i < v.NumField(); i++ {
+		f, field := v.Field(i), v.Type().Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		switch {
+		case f.Kind() == reflect.Struct:
+			m[key] = redact(f, prefix+key+".", resolved)
+		case field.Tag.Get("secret") == "true" || resolved[prefix+key]:
+			if f.IsZero() {
+				m[key] = ""
+			} else {
+				m[key] = redactedValue
+			}
+		case f.Type() == reflect.TypeOf(time.Duration(0)):
+			m[key] = f.Interface().(time.Duration).String()
+		default:
+			m[key] = f.Interface()
+		}
+	}
+	return m
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Dump(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"config.yaml":      baseConfig,
+		"config.prod.yaml": prodConfig,
+	})
+	defer os.RemoveAll(dir)
+
+	os.Setenv("APP_REDIS_PASSWORD", "redis-secret")
+	defer os.Unsetenv("APP_REDIS_PASSWORD")
+	os.Setenv("TEST_DB_PASS", "db-secret")
+	defer os.Unsetenv("TEST_DB_PASS")
+
+	c, err := NewLoader(filepath.Join(dir, "config.yaml"), "prod").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := c.Dump()
+
+	wantSources := map[string]string{
+		"redis.Password":           "env:APP_REDIS_PASSWORD",
+		"database.password":        "file:" + filepath.Join(dir, "config.prod.yaml"),
+		"logger.level":             "file:" + filepath.Join(dir, "config.prod.yaml"),
+		"database.port":            "default",
+		"http.port":                "file:" + filepath.Join(dir, "config.yaml"),
+		"http.http-server-timeout": "file:" + filepath.Join(dir, "config.prod.yaml"),
+	}
+	for key, want := range wantSources {
+		if got := d.Sources[key]; got != want {
+			t.Errorf("Config.Dump() source of %s = %v, want %v", key, got, want)
+		}
+	}
+
+	if got := d.Config["database"].(map[string]interface{})["password"]; got != redactedValue {
+		t.Errorf("Config.Dump() database.password = %v, want %v", got, redactedValue)
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		out, err := d.Marshal(format)
+		if err != nil {
+			t.Fatalf("Dump.Marshal(%s) error = %v", format, err)
+		}
+		if strings.Contains(string(out), "redis-secret") || strings.Contains(string(out), "db-secret") {
+			t.Errorf("Dump.Marshal(%s) leaks the passwords:\n%s", format, out)
+		}
+		if !strings.Contains(string(out), "10s") {
+			t.Errorf("Dump.Marshal(%s) does not render durations:\n%s", format, out)
+		}
+	}
+
+	if _, err := d.Marshal("xml"); err == nil {
+		t.Errorf("Dump.Marshal(xml) error = nil, want error")
+	}
+}
+
+func TestConfig_Dump_ResolvedField(t *testing.T) {
+	os.Setenv("TEST_DB_USER", "env-user")
+	defer os.Unsetenv("TEST_DB_USER")
+
+	c := validConfig()
+	c.Database.User = "env:TEST_DB_USER"
+	if err := resolveSecrets(context.Background(), c, NewLoader("config.yaml", "").resolvers); err != nil {
+		t.Fatal(err)
+	}
+	d := c.Dump()
+
+	database := d.Config["database"].(map[string]interface{})
+	if got := database["user"]; got != redactedValue {
+		t.Errorf("Config.Dump() database.user = %v, want %v", got, redactedValue)
+	}
+	if got := database["host"]; got != c.Database.Host {
+		t.Errorf("Config.Dump() database.host = %v, want %v", got, c.Database.Host)
+	}
+	out, err := d.Marshal("yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "env-user") {
+		t.Errorf("Dump.Marshal(yaml) leaks the resolved database.user:\n%s", out)
+	}
+}
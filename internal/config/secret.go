@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// Resolver resolves secret references. A configuration value of the form
+// "<scheme>:<ref>" is replaced by the value returned by the resolver
+// registered for scheme, e.g. "file:/run/secrets/db_password" or
+// "env:DB_PASS". Values whose scheme has no resolver are kept as is.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to a Resolver.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// FileResolver reads the secret from the file at ref, the trailing newline is
+// trimmed.
+var FileResolver = ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+	b, err := ioutil.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+})
+
+// EnvResolver reads the secret from the environment variable ref.
+var EnvResolver = ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return v, nil
+})
+
+// resolveSecrets replaces the secret references in the string fields of
+// config and records the resolved keys, every failure is reported.
+func resolveSecrets(ctx context.Context, config *Config, resolvers map[string]Resolver) error {
+	config.resolved = make(map[string]bool)
+	return resolveStruct(ctx, reflect.ValueOf(config).Elem(), "", resolvers, config.resolved)
+}
+
+func resolveStruct(ctx context.Context, v reflect.Value, prefix string, resolvers map[string]Resolver, resolved map[string]bool) error {
+	var err error
+	for i := 0; i < v.NumField(); i++ {
+		f, field := v.Field(i), prefix+v.Type().Field(i).Tag.Get("mapstructure")
+		switch f.Kind() {
+		case reflect.Struct:
+			err = multierr.Append(err, resolveStruct(ctx, f, field+".", resolvers, resolved))
+		case reflect.String:
+			parts := strings.SplitN(f.String(), ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			scheme, ref := parts[0], parts[1]
+			r, ok := resolvers[scheme]
+			if !ok {
+				continue
+			}
+			value, rerr := r.Resolve(ctx, ref)
+			if rerr != nil {
+				err = multierr.Append(err, fieldError(field, "failed to resolve %s secret: %v", scheme, rerr))
+				continue
+			}
+			f.SetString(value)
+			resolved[field] = true
+		}
+	}
+	return err
+}
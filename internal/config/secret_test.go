@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/multierr"
+)
+
+// vaultResolver is a vault-style resolver used against a local stand-in. A
+// reference "<path>#<key>" reads key of the KV secret at path.
+type vaultResolver struct {
+	addr  string
+	token string
+}
+
+func (r *vaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	parts := strings.SplitN(ref, "#", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid reference %q", ref)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.addr+"/v1/secret/data/"+parts[0], nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", r.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var body struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	v, ok := body.Data.Data[parts[1]]
+	if !ok {
+		return "", fmt.Errorf("key %s not found", parts[1])
+	}
+	return v, nil
+}
+
+func newVaultStandIn(secrets map[string]map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": data},
+		})
+	}))
+}
+
+func TestLoader_resolveSecrets(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"db_password": "file-secret\n",
+	})
+	defer os.RemoveAll(dir)
+
+	vault := newVaultStandIn(map[string]map[string]string{
+		"go-template/redis": {"password": "vault-secret"},
+	})
+	defer vault.Close()
+
+	os.Setenv("TEST_DB_USER", "env-user")
+	defer os.Unsetenv("TEST_DB_USER")
+
+	tests := []struct {
+		name       string
+		config     func(c *Config)
+		check      func(c *Config) bool
+		wantFields []string
+	}{
+		{
+			name: "file, env and vault references",
+			config: func(c *Config) {
+				c.Database.Password = "file:" + filepath.Join(dir, "db_password")
+				c.Database.User = "env:TEST_DB_USER"
+				c.Redis.Password = "vault:go-template/redis#password"
+			},
+			check: func(c *Config) bool {
+				return c.Database.Password == "file-secret" && c.Database.User == "env-user" &&
+					c.Redis.Password == "vault-secret"
+			},
+		},
+		{
+			name: "plain values are kept",
+			config: func(c *Config) {
+				c.Database.Password = "123456"
+				c.Redis.Host = "localhost:6379"
+			},
+			check: func(c *Config) bool {
+				return c.Database.Password == "123456" && c.Redis.Host == "localhost:6379"
+			},
+		},
+		{
+			name: "every failure is reported",
+			config: func(c *Config) {
+				c.Database.Password = "file:" + filepath.Join(dir, "missing")
+				c.Database.User = "env:TEST_MISSING"
+				c.Redis.Password = "vault:go-template/redis#missing"
+			},
+			wantFields: []string{"redis.Password", "database.user", "database.password"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLoader("config.yaml", "")
+			l.RegisterResolver("vault", &vaultResolver{addr: vault.URL, token: "token"})
+
+			c := validConfig()
+			tt.config(c)
+			errs := multierr.Errors(resolveSecrets(context.Background(), c, l.resolvers))
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("resolveSecrets() errors = %v, want fields %v", errs, tt.wantFields)
+			}
+			for i, err := range errs {
+				if fe, ok := err.(*FieldError); !ok || fe.Field != tt.wantFields[i] {
+					t.Errorf("resolveSecrets() error = %v, want field %v", err, tt.wantFields[i])
+				}
+			}
+			if tt.check != nil && !tt.check(c) {
+				t.Errorf("resolveSecrets() = %+v", c)
+			}
+		})
+	}
+}
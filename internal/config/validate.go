@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// logLevels are the levels accepted by log.New.
+var logLevels = map[string]bool{
+	"debug": true,
+	"info":  true,
+	"warn":  true,
+	"error": true,
+	"fatal": true,
+	"panic": true,
+}
+
+// clientAuthModes are the accepted values of http.tls.client-auth, with
+// whether they verify client certificates against the client CA.
+var clientAuthModes = map[string]bool{
+	"none":               false,
+	"request":            false,
+	"require":            false,
+	"verify-if-given":    true,
+	"require-and-verify": true,
+}
+
+// FieldError describes an invalid configuration field.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+func fieldError(field, format string, args ...interface{}) error {
+	return &FieldError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks the configuration and reports every problem at once.
+// The returned error can be split with multierr.Errors.
+func (c *Config) Validate() error {
+	return multierr.Combine(
+		c.HTTP.validate(),
+		c.GRPC.validate(c.HTTP),
+		c.Redis.validate(),
+		c.Logger.validate(),
+		c.Database.validate(),
+		c.validateSecrets(),
+	)
+}
+
+// validateSecrets rejects the secrets written as is in a configuration file
+// under the prod profile, they must be secret references or come from the
+// environment.
+func (c *Config) validateSecrets() error {
+	if c.profile != "prod" {
+		return nil
+	}
+	var err error
+	for _, secret := range secretValues(reflect.ValueOf(*c), "") {
+		key, value := secret[0], secret[1]
+		if value == "" || c.resolved[key] || !strings.HasPrefix(c.sources[key], SourceFile+":") {
+			continue
+		}
+		err = multierr.Append(err, fieldError(key, "literal secret in %s, use a secret reference or %s in the prod profile",
+			strings.TrimPrefix(c.sources[key], SourceFile+":"), envName(key)))
+	}
+	return err
+}
+
+// secretValues returns the dotted paths and values of the fields tagged with
+// `secret:"true"`, in the order of the fields.
+func secretValues(v reflect.Value, prefix string) [][2]string {
+	var values [][2]string
+	for i := 0; i < v.NumField(); i++ {
+		f, field := v.Field(i), v.Type().Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		switch {
+		case f.Kind() == reflect.Struct:
+			values = append(values, secretValues(f, prefix+key+".")...)
+		case field.Tag.Get("secret") == "true":
+			values = append(values, [2]string{prefix + key, f.String()})
+		}
+	}
+	return values
+}
+
+func (c HTTP) validate() error {
+	var err error
+	// port 0 disables the HTTP server
+	if c.Port != "0" {
+		err = multierr.Append(err, validatePort("http.port", c.Port))
+	}
+	if c.PortMetrics < 0 || c.PortMetrics > 65535 {
+		err = multierr.Append(err, fieldError("http.port-metrics", "port %d out of range", c.PortMetrics))
+	}
+	err = multierr.Append(err, validateTimeout("http.http-server-timeout", c.HTTPServerTimeout))
+	err = multierr.Append(err, validateTimeout("http.http-server-shutdown-timeout", c.HTTPServerShutdownTimeout))
+	if c.HTTPServerShutdownDelay < 0 {
+		err = multierr.Append(err, fieldError("http.http-server-shutdown-delay", "delay must not be negative"))
+	}
+	err = multierr.Append(err, c.TLS.validate())
+	err = multierr.Append(err, c.Errors.validate())
+	return err
+}
+
+func (c Errors) validate() error {
+	var err error
+	if c.Format != "envelope" && c.Format != "problem" {
+		err = multierr.Append(err, fieldError("http.errors.format", "unknown format %q, want envelope or problem", c.Format))
+	}
+	if u, e := url.Parse(c.TypeBase); e != nil || !u.IsAbs() {
+		err = multierr.Append(err, fieldError("http.errors.type-base", "%q is not an absolute URI", c.TypeBase))
+	}
+	return err
+}
+
+func (c TLS) validate() error {
+	var err error
+	if c.CertFile != "" && c.KeyFile == "" {
+		err = multierr.Append(err, fieldError("http.tls.key-file", "key is required with a certificate"))
+	}
+	if c.CertFile == "" && c.KeyFile != "" {
+		err = multierr.Append(err, fieldError("http.tls.cert-file", "certificate is required with a key"))
+	}
+	if !c.Enabled() && c.ClientCAFile != "" {
+		err = multierr.Append(err, fieldError("http.tls.client-ca-file", "client CA requires TLS to be enabled"))
+	}
+	if c.ClientAuth != "" {
+		verify, ok := clientAuthModes[c.ClientAuth]
+		switch {
+		case !ok:
+			err = multierr.Append(err, fieldError("http.tls.client-auth", "unknown client auth %q", c.ClientAuth))
+		case verify && c.ClientCAFile == "":
+			err = multierr.Append(err, fieldError("http.tls.client-auth", "client auth %q requires a client CA", c.ClientAuth))
+		}
+	}
+	return err
+}
+
+func (c GRPC) validate(http HTTP) error {
+	var err error
+	if c.Port < 0 || c.Port > 65535 {
+		err = multierr.Append(err, fieldError("grpc.port", "port %d out of range", c.Port))
+	}
+	// port 0 shares the HTTP port, which must then be served
+	if c.Port == 0 && http.Port == "0" {
+		err = multierr.Append(err, fieldError("grpc.port", "gRPC shares the HTTP port, which is disabled, give gRPC a port of its own"))
+	}
+	if c.Port != 0 && (strconv.Itoa(c.Port) == http.Port || c.Port == http.PortMetrics) {
+		err = multierr.Append(err, fieldError("grpc.port", "port %d is used by the HTTP or metrics server, use 0 to share the HTTP port", c.Port))
+	}
+	err = multierr.Append(err, c.Gateway.validate())
+	return err
+}
+
+func (c Gateway) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if !strings.HasPrefix(c.Prefix, "/") || strings.HasSuffix(c.Prefix, "/") {
+		return fieldError("grpc.gateway.prefix", "prefix %q must start and must not end with a slash", c.Prefix)
+	}
+	return nil
+}
+
+func (c Redis) validate() error {
+	var err error
+	if c.Host == "" {
+		err = multierr.Append(err, fieldError("redis.Host", "host is required"))
+	}
+	err = multierr.Append(err, validatePort("redis.Port", c.Port))
+	if c.MaxIdle < 0 {
+		err = multierr.Append(err, fieldError("redis.MaxIdle", "must not be negative"))
+	}
+	if c.DB < 0 {
+		err = multierr.Append(err, fieldError("redis.DB", "must not be negative"))
+	}
+	err = multierr.Append(err, validateTimeout("redis.ConnectTimeout", c.ConnectTimeout))
+	err = multierr.Append(err, validateTimeout("redis.ReadTimeout", c.ReadTimeout))
+	err = multierr.Append(err, validateTimeout("redis.WriteTimeout", c.WriteTimeout))
+	return err
+}
+
+func (c Logger) validate() error {
+	var err error
+	if !logLevels[c.Level] {
+		err = multierr.Append(err, fieldError("logger.level", "unknown log level %q", c.Level))
+	}
+	if len(c.OutputPaths) == 0 {
+		err = multierr.Append(err, fieldError("logger.output-paths", "at least one output path is required"))
+	}
+	if len(c.ErrorOutputPaths) == 0 {
+		err = multierr.Append(err, fieldError("logger.error-output-paths", "at least one output path is required"))
+	}
+	return err
+}
+
+func (c Database) validate() error {
+	var err error
+	if c.Host == "" {
+		err = multierr.Append(err, fieldError("database.host", "host is required"))
+	}
+	err = multierr.Append(err, validatePort("database.port", c.Port))
+	if c.User == "" {
+		err = multierr.Append(err, fieldError("database.user", "user is required"))
+	}
+	if c.DBName == "" {
+		err = multierr.Append(err, fieldError("database.dbname", "database name is required"))
+	}
+	err = multierr.Append(err, validateTimeout("database.timeout", c.Timeout))
+	err = multierr.Append(err, validateTimeout("database.read-timeout", c.ReadTimeout))
+	err = multierr.Append(err, validateTimeout("database.write-timeout", c.WriteTimeout))
+	return err
+}
+
+func validatePort(field, port string) error {
+	if port == "" {
+		return fieldError(field, "port is required")
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fieldError(field, "invalid port %q", port)
+	}
+	if n < 1 || n > 65535 {
+		return fieldError(field, "port %d out of range", n)
+	}
+	return nil
+}
+
+func validateTimeout(field string, d time.Duration) error {
+	if d <= 0 {
+		return fieldError(field, "timeout must be greater than zero")
+	}
+	return nil
+}
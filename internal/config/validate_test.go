@@ -0,0 +1,218 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+func validConfig() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Port:                      "8000",
+			PortMetrics:               9898,
+			HTTPServerTimeout:         30 * time.Second,
+			HTTPServerShutdownTimeout: 5 * time.Second,
+			Errors:                    Errors{Format: "envelope", TypeBase: "urn:go-template:errno:"},
+		},
+		Redis: Redis{
+			MaxIdle:        10,
+			IdleTimeout:    30 * time.Second,
+			ConnectTimeout: 5 * time.Second,
+			ReadTimeout:    5 * time.Second,
+			WriteTimeout:   5 * time.Second,
+			Host:           "127.0.0.1",
+			Port:           "6379",
+		},
+		Logger: Logger{
+			Level:            "info",
+			OutputPaths:      []string{"stderr"},
+			ErrorOutputPaths: []string{"stderr"},
+		},
+		Database: Database{
+			User:         "root",
+			DBName:       "test",
+			Host:         "127.0.0.1",
+			Port:         "3306",
+			Timeout:      30 * time.Second,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 5 * time.Second,
+		},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(c *Config)
+		wantFields []string
+	}{
+		{
+			name:       "valid",
+			modify:     func(c *Config) {},
+			wantFields: nil,
+		},
+		{
+			name: "http disabled",
+			modify: func(c *Config) {
+				c.HTTP.Port = "0"
+				c.GRPC.Port = 9090
+			},
+			wantFields: nil,
+		},
+		{
+			name:       "bad http port",
+			modify:     func(c *Config) { c.HTTP.Port = "80a" },
+			wantFields: []string{"http.port"},
+		},
+		{
+			name: "problem errors",
+			modify: func(c *Config) {
+				c.HTTP.Errors = Errors{Format: "problem", TypeBase: "https://docs.example.com/errors/"}
+			},
+			wantFields: nil,
+		},
+		{
+			name:       "unknown error format",
+			modify:     func(c *Config) { c.HTTP.Errors.Format = "xml" },
+			wantFields: []string{"http.errors.format"},
+		},
+		{
+			name:       "relative problem type base",
+			modify:     func(c *Config) { c.HTTP.Errors.TypeBase = "/errors/" },
+			wantFields: []string{"http.errors.type-base"},
+		},
+		{
+			name:       "unknown log level",
+			modify:     func(c *Config) { c.Logger.Level = "verbose" },
+			wantFields: []string{"logger.level"},
+		},
+		{
+			name:       "grpc on its own port",
+			modify:     func(c *Config) { c.GRPC.Port = 9000 },
+			wantFields: nil,
+		},
+		{
+			name:       "grpc on the http port",
+			modify:     func(c *Config) { c.GRPC.Port = 8000 },
+			wantFields: []string{"grpc.port"},
+		},
+		{
+			name: "gateway prefix with a trailing slash",
+			modify: func(c *Config) {
+				c.GRPC.Gateway = Gateway{Enabled: true, Prefix: "/api/"}
+			},
+			wantFields: []string{"grpc.gateway.prefix"},
+		},
+		{
+			name: "mutual tls",
+			modify: func(c *Config) {
+				c.HTTP.TLS = TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt"}
+			},
+			wantFields: nil,
+		},
+		{
+			name:       "tls without key",
+			modify:     func(c *Config) { c.HTTP.TLS.CertFile = "tls.crt" },
+			wantFields: []string{"http.tls.key-file"},
+		},
+		{
+			name: "client verification without client ca",
+			modify: func(c *Config) {
+				c.HTTP.TLS = TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: "require-and-verify"}
+			},
+			wantFields: []string{"http.tls.client-auth"},
+		},
+		{
+			name: "unknown client auth",
+			modify: func(c *Config) {
+				c.HTTP.TLS = TLS{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: "always"}
+			},
+			wantFields: []string{"http.tls.client-auth"},
+		},
+		{
+			name: "gRPC without a port",
+			modify: func(c *Config) {
+				c.HTTP.Port = "0"
+				c.GRPC.Port = 0
+			},
+			wantFields: []string{"grpc.port"},
+		},
+		{
+			name: "negative shutdown delay",
+			modify: func(c *Config) {
+				c.HTTP.HTTPServerShutdownDelay = -time.Second
+			},
+			wantFields: []string{"http.http-server-shutdown-delay"},
+		},
+		{
+			name: "literal secrets in a prod file",
+			modify: func(c *Config) {
+				c.Redis.Password, c.Database.Password = "123456", "123456"
+				c.profile = "prod"
+				c.sources = map[string]string{
+					"redis.Password":    "file:configs/config.yaml",
+					"database.password": "file:configs/config.yaml",
+				}
+			},
+			wantFields: []string{"redis.Password", "database.password"},
+		},
+		{
+			name: "secrets from references and the environment in prod",
+			modify: func(c *Config) {
+				c.Redis.Password, c.Database.Password = "123456", "123456"
+				c.profile = "prod"
+				c.sources = map[string]string{
+					"redis.Password":    "env:APP_REDIS_PASSWORD",
+					"database.password": "file:configs/config.prod.yaml",
+				}
+				c.resolved = map[string]bool{"database.password": true}
+			},
+		},
+		{
+			name: "literal secrets out of prod",
+			modify: func(c *Config) {
+				c.Redis.Password, c.Database.Password = "123456", "123456"
+				c.profile = "staging"
+				c.sources = map[string]string{"database.password": "file:configs/config.yaml"}
+			},
+		},
+		{
+			name: "multiple errors",
+			modify: func(c *Config) {
+				c.Redis.Host = ""
+				c.Redis.ReadTimeout = 0
+				c.Database.Host = ""
+				c.Database.Port = "70000"
+				c.HTTP.HTTPServerShutdownTimeout = 0
+			},
+			wantFields: []string{
+				"http.http-server-shutdown-timeout",
+				"redis.Host",
+				"redis.ReadTimeout",
+				"database.host",
+				"database.port",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+			errs := multierr.Errors(c.Validate())
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("Config.Validate() errors = %v, want fields %v", errs, tt.wantFields)
+			}
+			for i, err := range errs {
+				fe, ok := err.(*FieldError)
+				if !ok {
+					t.Fatalf("Config.Validate() error %v is not a *FieldError", err)
+				}
+				if fe.Field != tt.wantFields[i] {
+					t.Errorf("Config.Validate() field = %v, want %v", fe.Field, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
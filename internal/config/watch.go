@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Watcher watches the configuration files and notifies subscribers of the
+// sections that changed. Changes that fail validation are rejected and the
+// previous configuration is kept.
+type Watcher struct {
+	loader *Loader
+	// reload serializes reloads triggered by the watchers of each file
+	reload   sync.Mutex
+	mu       sync.Mutex
+	current  *Config
+	http     []func(old, new HTTP)
+	grpc     []func(old, new GRPC)
+	redis    []func(old, new Redis)
+	logger   []func(old, new Logger)
+	database []func(old, new Database)
+}
+
+// NewWatcher returns a Watcher for the configuration loaded by loader.
+func NewWatcher(loader *Loader, config *Config) *Watcher {
+	return &Watcher{
+		loader:  loader,
+		current: config,
+	}
+}
+
+// OnHTTPChange registers fn to be called when the http section changes.
+func (w *Watcher) OnHTTPChange(fn func(old, new HTTP)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.http = append(w.http, fn)
+}
+
+// OnGRPCChange registers fn to be called when the grpc section changes.
+func (w *Watcher) OnGRPCChange(fn func(old, new GRPC)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.grpc = append(w.grpc, fn)
+}
+
+// OnRedisChange registers fn to be called when the redis section changes.
+func (w *Watcher) OnRedisChange(fn func(old, new Redis)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.redis = append(w.redis, fn)
+}
+
+// OnLoggerChange registers fn to be called when the logger section changes.
+func (w *Watcher) OnLoggerChange(fn func(old, new Logger)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logger = append(w.logger, fn)
+}
+
+// OnDatabaseChange registers fn to be called when the database section changes.
+func (w *Watcher) OnDatabaseChange(fn func(old, new Database)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.database = append(w.database, fn)
+}
+
+// Current returns the latest valid configuration.
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Start begins watching the configuration files. Any change reloads every
+// layer so that the profile overlay and environment variables still apply.
+func (w *Watcher) Start() {
+	for _, file := range w.loader.Files() {
+		v := viper.New()
+		v.SetConfigFile(file)
+		v.OnConfigChange(func(e fsnotify.Event) {
+			w.reload.Lock()
+			defer w.reload.Unlock()
+
+			config, err := w.loader.Load()
+			if err != nil {
+				zap.L().Error("failed to reload configuration, keeping previous configuration",
+					zap.String("file", e.Name), zap.Error(err))
+				return
+			}
+			w.apply(config)
+		})
+		v.WatchConfig()
+	}
+}
+
+// apply replaces the current configuration and notifies subscribers of
+// every section that changed.
+func (w *Watcher) apply(config *Config) {
+	w.mu.Lock()
+	old := w.current
+	w.current = config
+	httpSubs, grpcSubs, redisSubs := w.http, w.grpc, w.redis
+	loggerSubs, databaseSubs := w.logger, w.database
+	w.mu.Unlock()
+
+	// the logger goes first so that other subscribers log with the new level
+	if !reflect.DeepEqual(old.Logger, config.Logger) {
+		for _, fn := range loggerSubs {
+			fn(old.Logger, config.Logger)
+		}
+	}
+	if !reflect.DeepEqual(old.HTTP, config.HTTP) {
+		for _, fn := range httpSubs {
+			fn(old.HTTP, config.HTTP)
+		}
+	}
+	if !reflect.DeepEqual(old.GRPC, config.GRPC) {
+		for _, fn := range grpcSubs {
+			fn(old.GRPC, config.GRPC)
+		}
+	}
+	if !reflect.DeepEqual(old.Redis, config.Redis) {
+		for _, fn := range redisSubs {
+			fn(old.Redis, config.Redis)
+		}
+	}
+	if !reflect.DeepEqual(old.Database, config.Database) {
+		for _, fn := range databaseSubs {
+			fn(old.Database, config.Database)
+		}
+	}
+}
+
+// Diff returns the mapstructure keys of the fields that differ between two
+// values of the same configuration section.
+func Diff(old, new interface{}) []string {
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	if ov.Type() != nv.Type() || ov.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("config: can not diff %T and %T", old, new))
+	}
+
+	var fields []string
+	for i := 0; i < ov.NumField(); i++ {
+		if reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		f := ov.Type().Field(i)
+		name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
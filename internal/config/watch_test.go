@@ -0,0 +1,77 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  interface{}
+		new  interface{}
+		want []string
+	}{
+		{
+			name: "no change",
+			old:  HTTP{Port: "8000"},
+			new:  HTTP{Port: "8000"},
+			want: nil,
+		},
+		{
+			name: "http fields",
+			old:  HTTP{Port: "8000", HTTPServerTimeout: time.Second},
+			new:  HTTP{Port: "8001", HTTPServerTimeout: 2 * time.Second},
+			want: []string{"port", "http-server-timeout"},
+		},
+		{
+			name: "slice field",
+			old:  Logger{Level: "info", OutputPaths: []string{"stderr"}},
+			new:  Logger{Level: "info", OutputPaths: []string{"stdout"}},
+			want: []string{"output-paths"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcher_apply(t *testing.T) {
+	old := validConfig()
+	w := NewWatcher(NewLoader("config.yaml", ""), old)
+
+	var (
+		gotLogger   *Logger
+		httpCalls   int
+		redisCalls  int
+		dbCalls     int
+		loggerCalls int
+	)
+	w.OnLoggerChange(func(_, new Logger) {
+		loggerCalls++
+		gotLogger = &new
+	})
+	w.OnHTTPChange(func(_, _ HTTP) { httpCalls++ })
+	w.OnRedisChange(func(_, _ Redis) { redisCalls++ })
+	w.OnDatabaseChange(func(_, _ Database) { dbCalls++ })
+
+	changed := validConfig()
+	changed.Logger.Level = "debug"
+	w.apply(changed)
+
+	if loggerCalls != 1 || httpCalls != 0 || redisCalls != 0 || dbCalls != 0 {
+		t.Errorf("Watcher.apply() calls logger=%d http=%d redis=%d database=%d, want only logger",
+			loggerCalls, httpCalls, redisCalls, dbCalls)
+	}
+	if gotLogger == nil || gotLogger.Level != "debug" {
+		t.Errorf("Watcher.apply() logger = %v, want level debug", gotLogger)
+	}
+	if w.Current() != changed {
+		t.Errorf("Watcher.Current() was not updated")
+	}
+}
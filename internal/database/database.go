@@ -0,0 +1,32 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"go-template/internal/config"
+)
+
+// Connect connects to the mysql database and verifies the connection.
+func Connect(c config.Database) (*sqlx.DB, error) {
+	config := &mysql.Config{
+		User:                 c.User,
+		Passwd:               c.Password,
+		DBName:               c.DBName,
+		Net:                  "tcp",
+		Addr:                 fmt.Sprintf("%s:%s", c.Host, c.Port),
+		Loc:                  time.Local,
+		Timeout:              c.Timeout,
+		ReadTimeout:          c.ReadTimeout,
+		WriteTimeout:         c.WriteTimeout,
+		AllowNativePasswords: true,
+		CheckConnLiveness:    true,
+		// rows matched rather than changed, so that an update to the same
+		// values is not mistaken for a missing row
+		ClientFoundRows: true,
+	}
+	return sqlx.Connect("mysql", config.FormatDSN())
+}
@@ -0,0 +1,50 @@
+package errno
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// catalogEntry is a definition as exported, with the gRPC code by name.
+type catalogEntry struct {
+	Code       int      `json:"code"`
+	Message    string   `json:"message"`
+	HTTPStatus int      `json:"httpStatus"`
+	GRPCCode   string   `json:"grpcCode"`
+	Category   Category `json:"category"`
+}
+
+// MarshalCatalog renders the catalog of the registered codes, see Catalog,
+// as "markdown" or "json", e.g. for clients to generate their error handling
+// from it.
+func MarshalCatalog(format string) ([]byte, error) {
+	entries := make([]catalogEntry, 0)
+	for _, d := range Catalog() {
+		entries = append(entries, catalogEntry{
+			Code:       d.Code,
+			Message:    d.Message,
+			HTTPStatus: d.HTTPStatus,
+			GRPCCode:   d.GRPCCode.String(),
+			Category:   d.Category,
+		})
+	}
+
+	switch format {
+	case "json":
+		return json.MarshalIndent(entries, "", "  ")
+	case "markdown":
+		var buf bytes.Buffer
+		buf.WriteString("| Code | Category | HTTP status | gRPC code | Message |\n")
+		buf.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, e := range entries {
+			fmt.Fprintf(&buf, "| %d | %s | %d %s | %s | %s |\n",
+				e.Code, e.Category, e.HTTPStatus, http.StatusText(e.HTTPStatus), e.GRPCCode,
+				strings.ReplaceAll(e.Message, "|", `\|`))
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown format %q, want markdown or json", format)
+}
@@ -1,6 +1,47 @@
 package errno
 
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
 var (
-	ErrServer = New(10001, "服务异常，请联系管理员")
-	ErrParam  = New(10002, "参数有误")
+	ErrServer = Register(Definition{
+		Code:       10001,
+		Message:    "服务异常，请联系管理员",
+		HTTPStatus: http.StatusInternalServerError,
+		GRPCCode:   codes.Internal,
+		Category:   CategoryServer,
+	})
+	ErrParam = Register(Definition{
+		Code:       10002,
+		Message:    "参数有误",
+		HTTPStatus: http.StatusBadRequest,
+		GRPCCode:   codes.InvalidArgument,
+		Category:   CategoryClient,
+	})
+	ErrNotFound = Register(Definition{
+		Code:       10003,
+		Message:    "资源不存在",
+		HTTPStatus: http.StatusNotFound,
+		GRPCCode:   codes.NotFound,
+		Category:   CategoryClient,
+	})
+	// ErrTokenExpired is returned when a watch can not be resumed, the client
+	// must read the resources again.
+	ErrTokenExpired = Register(Definition{
+		Code:       10004,
+		Message:    "恢复令牌已过期，请重新同步",
+		HTTPStatus: http.StatusGone,
+		GRPCCode:   codes.FailedPrecondition,
+		Category:   CategoryClient,
+	})
+	ErrUnavailable = Register(Definition{
+		Code:       10005,
+		Message:    "服务暂不可用，请稍后重试",
+		HTTPStatus: http.StatusServiceUnavailable,
+		GRPCCode:   codes.Unavailable,
+		Category:   CategoryServer,
+	})
 )
@@ -0,0 +1,13 @@
+package errno
+
+import "golang.org/x/text/language"
+
+func init() {
+	RegisterMessages(language.English, map[int]string{
+		10001: "Internal server error, please contact the administrator",
+		10002: "Invalid parameters",
+		10003: "Resource not found",
+		10004: "The resume token has expired, please resync",
+		10005: "Service temporarily unavailable, please retry later",
+	})
+}
@@ -1,5 +1,12 @@
 package errno
 
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
 type CustomError interface {
 	error
 	WithError(err error) CustomError
@@ -9,20 +16,77 @@ type customError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Detail  string `json:"detail"`
+
+	cause error
+	stack []uintptr
 }
 
-func (e customError) Error() string {
+func (e *customError) Error() string {
 	return e.Message
 }
 
-func (e customError) WithError(err error) CustomError {
-	e.Detail = err.Error()
-	return e
+// WithError returns a copy of the error caused by err, which is kept for
+// errors.Is and errors.As and whose message becomes the Detail. The stack
+// of the caller is recorded if CaptureStacks is enabled.
+func (e *customError) WithError(err error) CustomError {
+	c := *e
+	c.Detail = err.Error()
+	c.cause = err
+	c.stack = nil
+	if atomic.LoadInt32(&captureStacks) == 1 {
+		pcs := make([]uintptr, 32)
+		c.stack = pcs[:runtime.Callers(2, pcs)]
+	}
+	return &c
+}
+
+// Unwrap returns the cause given to WithError.
+func (e *customError) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an errno error with the same code, so that
+// errors.Is(err, errno.ErrNotFound) matches whatever the cause.
+func (e *customError) Is(target error) bool {
+	t, ok := target.(*customError)
+	return ok && t.Code == e.Code
 }
 
+// New registers a code with message and the defaults of Register, and
+// returns its error. It panics if the code is already registered.
 func New(code int, message string) CustomError {
-	return &customError{
-		Code:    code,
-		Message: message,
+	return Register(Definition{Code: code, Message: message})
+}
+
+var captureStacks int32
+
+// CaptureStacks enables or disables recording the stack of the callers of
+// WithError, see Stack. It is disabled by default as it costs an allocation
+// and a stack walk per error.
+func CaptureStacks(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&captureStacks, v)
+}
+
+// Stack returns the stack recorded by WithError for the first errno error
+// in the chain of err, one "function\n\tfile:line" frame per line. It is
+// empty if CaptureStacks was disabled or err is not an errno error.
+func Stack(err error) string {
+	e, ok := as(err)
+	if !ok || len(e.stack) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
 	}
+	return b.String()
 }
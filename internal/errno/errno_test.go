@@ -0,0 +1,59 @@
+package errno
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWithError(t *testing.T) {
+	err := fmt.Errorf("get user: %w", ErrNotFound.WithError(sql.ErrNoRows))
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("errors.Is(err, ErrNotFound) = false, want true")
+	}
+	if errors.Is(err, ErrServer) {
+		t.Error("errors.Is(err, ErrServer) = true, want false")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Error("errors.Is(err, sql.ErrNoRows) = false, want the cause")
+	}
+	if !errors.Is(FromError(err), ErrNotFound) {
+		t.Error("errors.Is(FromError(err), ErrNotFound) = false, want true")
+	}
+
+	var e CustomError
+	if !errors.As(err, &e) || e.Error() != "资源不存在" {
+		t.Errorf("errors.As() = %v, want ErrNotFound", e)
+	}
+
+	if got := Detail(err); got != sql.ErrNoRows.Error() {
+		t.Errorf("Detail() = %q, want the cause", got)
+	}
+	if Detail(ErrNotFound) != "" {
+		t.Error("WithError modified the registered error")
+	}
+}
+
+func TestStack(t *testing.T) {
+	cause := errors.New("connection refused")
+	if got := Stack(ErrServer.WithError(cause)); got != "" {
+		t.Errorf("Stack() = %q, want none while disabled", got)
+	}
+
+	CaptureStacks(true)
+	defer CaptureStacks(false)
+	err := fmt.Errorf("list books: %w", ErrServer.WithError(cause))
+	stack := Stack(err)
+	if !strings.HasPrefix(stack, "go-template/internal/errno.TestStack\n\t") || !strings.Contains(stack, "errno_test.go:") {
+		t.Errorf("Stack() = %q, want the caller of WithError first", stack)
+	}
+	if got := Stack(ErrServer); got != "" {
+		t.Errorf("Stack() of a registered error = %q, want none", got)
+	}
+	if got := Stack(cause); got != "" {
+		t.Errorf("Stack() of a plain error = %q, want none", got)
+	}
+}
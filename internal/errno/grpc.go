@@ -0,0 +1,64 @@
+package errno
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiv1 "go-template/api/proto/v1"
+)
+
+// Code returns the errno code of err, 0 if err is not an errno error.
+func Code(err error) int {
+	e, ok := as(err)
+	if !ok {
+		return 0
+	}
+	return e.Code
+}
+
+// as finds the first errno error in the chain of err.
+func as(err error) (*customError, bool) {
+	var e *customError
+	return e, errors.As(err, &e)
+}
+
+// GRPCStatus converts the error to a gRPC status, it is called by grpc-go
+// when a handler returns the error. The errno code and message are carried
+// in an apiv1.Error detail, Detail is left out as it may leak internals.
+func (e *customError) GRPCStatus() *status.Status {
+	code := codes.Unknown
+	if d, ok := Lookup(e.Code); ok {
+		code = d.GRPCCode
+	}
+	st := status.New(code, e.Message)
+	if detailed, err := st.WithDetails(&apiv1.Error{Code: int32(e.Code), Message: e.Message}); err == nil {
+		st = detailed
+	}
+	return st
+}
+
+// FromError converts an error returned by a gRPC client to an errno error.
+// The errno code and message are taken from the status details. Statuses
+// without them are mapped from their gRPC code, with the status message as
+// Detail. It returns nil if err is nil.
+func FromError(err error) CustomError {
+	if err == nil {
+		return nil
+	}
+	if e, ok := as(err); ok {
+		return e
+	}
+
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*apiv1.Error); ok {
+			return &customError{Code: int(d.Code), Message: d.Message, cause: err}
+		}
+	}
+	if d, ok := fromGRPCCode(st.Code()); ok {
+		return &customError{Code: d.Code, Message: d.Message, Detail: st.Message(), cause: err}
+	}
+	return ErrServer.WithError(errors.New(st.Message()))
+}
@@ -0,0 +1,109 @@
+package errno
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantMessage string
+		wantErrno   int
+	}{
+		{
+			name:        "param",
+			err:         ErrParam,
+			wantCode:    codes.InvalidArgument,
+			wantMessage: "参数有误",
+			wantErrno:   10002,
+		},
+		{
+			name:        "server error with cause",
+			err:         ErrServer.WithError(errors.New("connection refused")),
+			wantCode:    codes.Internal,
+			wantMessage: "服务异常，请联系管理员",
+			wantErrno:   10001,
+		},
+		{
+			name:        "not found",
+			err:         ErrNotFound.WithError(errors.New("user 1 not found")),
+			wantCode:    codes.NotFound,
+			wantMessage: "资源不存在",
+			wantErrno:   10003,
+		},
+		{
+			name:        "code with the default mapping",
+			err:         errDefaults,
+			wantCode:    codes.Unknown,
+			wantMessage: "余额不足",
+			wantErrno:   20001,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// as done by grpc-go when a handler returns the error
+			st, _ := status.FromError(tt.err)
+			if st.Code() != tt.wantCode || st.Message() != tt.wantMessage {
+				t.Errorf("status = %v %q, want %v %q", st.Code(), st.Message(), tt.wantCode, tt.wantMessage)
+			}
+			if len(st.Details()) != 1 {
+				t.Fatalf("status details = %v, want the errno detail", st.Details())
+			}
+
+			// as received by a client
+			got := FromError(st.Err())
+			if Code(got) != tt.wantErrno || got.Error() != tt.wantMessage {
+				t.Errorf("FromError() = %d %q, want %d %q", Code(got), got.Error(), tt.wantErrno, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantErrno int
+	}{
+		{
+			name:      "nil",
+			err:       nil,
+			wantErrno: 0,
+		},
+		{
+			name:      "status without details",
+			err:       status.Error(codes.NotFound, "no such user"),
+			wantErrno: 10003,
+		},
+		{
+			name:      "unavailable",
+			err:       status.Error(codes.Unavailable, "connection refused"),
+			wantErrno: 10005,
+		},
+		{
+			name:      "unmapped status",
+			err:       status.Error(codes.DataLoss, "truncated"),
+			wantErrno: 10001,
+		},
+		{
+			name:      "wrapped errno error",
+			err:       fmt.Errorf("get user: %w", ErrParam),
+			wantErrno: 10002,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromError(tt.err)
+			if code := Code(got); code != tt.wantErrno {
+				t.Errorf("Code(FromError()) = %v, want %v", code, tt.wantErrno)
+			}
+		})
+	}
+}
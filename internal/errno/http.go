@@ -0,0 +1,22 @@
+package errno
+
+import "net/http"
+
+// HTTPStatus returns the HTTP status of err, 500 Internal Server Error if err
+// is not an errno error or its code is not registered.
+func HTTPStatus(err error) int {
+	if d, ok := Lookup(Code(err)); ok {
+		return d.HTTPStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// Detail returns the detail of err, the cause given to WithError. It is empty
+// if err is not an errno error or has no cause.
+func Detail(err error) string {
+	e, ok := as(err)
+	if !ok {
+		return ""
+	}
+	return e.Detail
+}
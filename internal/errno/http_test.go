@@ -0,0 +1,53 @@
+package errno
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "param",
+			err:  ErrParam,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "wrapped not found with cause",
+			err:  fmt.Errorf("get book: %w", ErrNotFound.WithError(errors.New("sql: no rows"))),
+			want: http.StatusNotFound,
+		},
+		{
+			name: "code with the default mapping",
+			err:  errDefaults,
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "not an errno error",
+			err:  errors.New("connection refused"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPStatus(tt.err); got != tt.want {
+				t.Errorf("HTTPStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetail(t *testing.T) {
+	if got := Detail(ErrServer.WithError(errors.New("connection refused"))); got != "connection refused" {
+		t.Errorf("Detail() = %q, want the cause", got)
+	}
+	if got := Detail(errors.New("connection refused")); got != "" {
+		t.Errorf("Detail() of a plain error = %q, want empty", got)
+	}
+}
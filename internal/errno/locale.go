@@ -0,0 +1,127 @@
+package errno
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultLanguage is the language of the messages given to Register, used
+// when none of the languages accepted by the client has a catalog.
+var DefaultLanguage = language.Chinese
+
+var catalogs = struct {
+	sync.RWMutex
+	messages map[language.Tag]map[int]string
+	// languages are the languages with a catalog, DefaultLanguage first
+	languages []language.Tag
+	matcher   language.Matcher
+}{
+	messages:  make(map[language.Tag]map[int]string),
+	languages: []language.Tag{DefaultLanguage},
+	matcher:   language.NewMatcher([]language.Tag{DefaultLanguage}),
+}
+
+// RegisterMessages adds the messages of codes, by code, to the catalog of
+// tag. The codes without a message in the catalog keep their default one.
+func RegisterMessages(tag language.Tag, messages map[int]string) {
+	catalogs.Lock()
+	defer catalogs.Unlock()
+	if tag == DefaultLanguage {
+		panic("errno: the messages of the default language are given to Register")
+	}
+	catalog, ok := catalogs.messages[tag]
+	if !ok {
+		catalog = make(map[int]string, len(messages))
+		catalogs.messages[tag] = catalog
+		catalogs.languages = append(catalogs.languages, tag)
+		catalogs.matcher = language.NewMatcher(catalogs.languages)
+	}
+	for code, message := range messages {
+		catalog[code] = message
+	}
+}
+
+// Languages returns the languages with a catalog, DefaultLanguage first.
+func Languages() []language.Tag {
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	return append([]language.Tag(nil), catalogs.languages...)
+}
+
+// Localize returns err with the message of its errno error in the language
+// that best matches acceptLanguage, an Accept-Language header such as
+// "en-US,en;q=0.9". The returned error wraps err, whose Error is kept for
+// logging, and renders the translated message to clients through
+// GRPCStatus and Message. err is returned unchanged if it is not an errno
+// error or its message needs no translation.
+func Localize(err error, acceptLanguage string) error {
+	e, ok := as(err)
+	if !ok {
+		return err
+	}
+	message, ok := localMessage(e.Code, acceptLanguage)
+	if !ok || message == e.Message {
+		return err
+	}
+	c := *e
+	c.Message = message
+	return &localizedError{err: err, localized: &c}
+}
+
+// Message returns the message of err to render to clients: the translated
+// one if err was returned by Localize, the one of its errno error
+// otherwise. It is empty if err is not an errno error.
+func Message(err error) string {
+	var l *localizedError
+	if errors.As(err, &l) {
+		return l.localized.Message
+	}
+	if e, ok := as(err); ok {
+		return e.Message
+	}
+	return ""
+}
+
+// localizedError is an error returned by Localize.
+type localizedError struct {
+	err       error
+	localized *customError
+}
+
+func (e *localizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *localizedError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus converts the error to a gRPC status with the translated
+// message, see customError.GRPCStatus.
+func (e *localizedError) GRPCStatus() *status.Status {
+	return e.localized.GRPCStatus()
+}
+
+// localMessage returns the message of code in the language that best
+// matches acceptLanguage.
+func localMessage(code int, acceptLanguage string) (string, bool) {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return "", false
+	}
+
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	// the messages of the default language, the first one, are in the
+	// registry
+	if _, i, confidence := catalogs.matcher.Match(tags...); i > 0 && confidence != language.No {
+		if message, ok := catalogs.messages[catalogs.languages[i]][code]; ok {
+			return message, true
+		}
+	}
+	d, ok := Lookup(code)
+	return d.Message, ok
+}
@@ -0,0 +1,118 @@
+package errno
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/status"
+)
+
+func TestLocalize(t *testing.T) {
+	cause := errors.New("sql: no rows")
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		wantMessage    string
+	}{
+		{
+			name:           "english",
+			err:            ErrNotFound,
+			acceptLanguage: "en",
+			wantMessage:    "Resource not found",
+		},
+		{
+			name:           "regional variant",
+			err:            ErrParam,
+			acceptLanguage: "en-GB",
+			wantMessage:    "Invalid parameters",
+		},
+		{
+			name:           "preferred default language",
+			err:            ErrParam,
+			acceptLanguage: "en;q=0.5, zh-CN;q=0.9",
+			wantMessage:    "参数有误",
+		},
+		{
+			name:           "no catalog falls back to the default language",
+			err:            ErrParam,
+			acceptLanguage: "fr-FR",
+			wantMessage:    "参数有误",
+		},
+		{
+			name:        "no header",
+			err:         ErrParam,
+			wantMessage: "参数有误",
+		},
+		{
+			name:           "invalid header",
+			err:            ErrParam,
+			acceptLanguage: "en;q=abc",
+			wantMessage:    "参数有误",
+		},
+		{
+			name:           "wrapped with a cause",
+			err:            fmt.Errorf("get user: %w", ErrNotFound.WithError(cause)),
+			acceptLanguage: "en-US",
+			wantMessage:    "Resource not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Localize(tt.err, tt.acceptLanguage)
+			if got := Message(err); got != tt.wantMessage {
+				t.Errorf("Message(Localize()) = %q, want %q", got, tt.wantMessage)
+			}
+			if st := status.Convert(err); st.Message() != tt.wantMessage {
+				t.Errorf("status message of Localize() = %q, want %q", st.Message(), tt.wantMessage)
+			}
+			// the error itself is kept, for logs and errors.Is
+			if err.Error() != tt.err.Error() {
+				t.Errorf("Localize().Error() = %q, want %q", err.Error(), tt.err.Error())
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("Localize() does not wrap err")
+			}
+			if Code(err) != Code(tt.err) || Detail(err) != Detail(tt.err) {
+				t.Errorf("Localize() = %d %q, want the code and detail of %v", Code(err), Detail(err), tt.err)
+			}
+			if tt.wantMessage == Message(tt.err) && err != tt.err {
+				t.Errorf("Localize() = %#v, want err unchanged without translation", err)
+			}
+		})
+	}
+
+	if got := ErrParam.Error(); got != "参数有误" {
+		t.Errorf("Localize() modified the registered error, message %q", got)
+	}
+	plain := errors.New("connection refused")
+	if got := Localize(plain, "en"); got != plain {
+		t.Errorf("Localize() of a plain error = %v, want it unchanged", got)
+	}
+}
+
+func TestMessagesTranslated(t *testing.T) {
+	languages := Languages()
+	if languages[0] != DefaultLanguage {
+		t.Fatalf("Languages() = %v, want %v first", languages, DefaultLanguage)
+	}
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	for _, tag := range languages[1:] {
+		for _, d := range Catalog() {
+			if catalogs.messages[tag][d.Code] == "" {
+				t.Errorf("code %d has no %v message", d.Code, tag)
+			}
+		}
+	}
+}
+
+func TestRegisterMessages_DefaultLanguage(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterMessages() of the default language did not panic")
+		}
+	}()
+	RegisterMessages(DefaultLanguage, map[int]string{10002: "参数缺失"})
+}
@@ -0,0 +1,101 @@
+package errno
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Category groups the codes by who must act on the error.
+type Category string
+
+// Categories of codes.
+const (
+	// CategoryClient errors are fixed by changing the request.
+	CategoryClient Category = "client"
+	// CategoryServer errors are failures of the server or its dependencies,
+	// the request may succeed later.
+	CategoryServer Category = "server"
+)
+
+// Definition defines an error code.
+type Definition struct {
+	Code int
+	// Message is the default message of the code.
+	Message    string
+	HTTPStatus int
+	GRPCCode   codes.Code
+	Category   Category
+}
+
+var registry = struct {
+	sync.RWMutex
+	definitions map[int]Definition
+}{definitions: make(map[int]Definition)}
+
+// Register registers the definition of a code and returns its error. The
+// HTTP status defaults to 500 Internal Server Error, the gRPC code to Unknown
+// and the category to CategoryServer. It panics if the code is already
+// registered: codes are registered when initializing package variables, so
+// a duplicate is caught when the program starts.
+func Register(d Definition) CustomError {
+	if d.HTTPStatus == 0 {
+		d.HTTPStatus = http.StatusInternalServerError
+	}
+	if d.GRPCCode == codes.OK {
+		d.GRPCCode = codes.Unknown
+	}
+	if d.Category == "" {
+		d.Category = CategoryServer
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if prev, ok := registry.definitions[d.Code]; ok {
+		panic(fmt.Sprintf("errno: code %d registered twice, by %q and %q", d.Code, prev.Message, d.Message))
+	}
+	registry.definitions[d.Code] = d
+	return &customError{
+		Code:    d.Code,
+		Message: d.Message,
+	}
+}
+
+// Lookup returns the definition of a registered code.
+func Lookup(code int) (Definition, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	d, ok := registry.definitions[code]
+	return d, ok
+}
+
+// Catalog returns the definitions of the registered codes, sorted by code.
+func Catalog() []Definition {
+	registry.RLock()
+	defer registry.RUnlock()
+	definitions := make([]Definition, 0, len(registry.definitions))
+	for _, d := range registry.definitions {
+		definitions = append(definitions, d)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Code < definitions[j].Code
+	})
+	return definitions
+}
+
+// fromGRPCCode returns the definition of the lowest code registered with
+// the gRPC code, which is not Unknown.
+func fromGRPCCode(code codes.Code) (Definition, bool) {
+	if code == codes.Unknown {
+		return Definition{}, false
+	}
+	for _, d := range Catalog() {
+		if d.GRPCCode == code {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
@@ -0,0 +1,91 @@
+package errno
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+)
+
+// errDefaults is registered by New, with the default mapping.
+var errDefaults = New(20001, "余额不足")
+
+func init() {
+	RegisterMessages(language.English, map[int]string{20001: "Insufficient balance"})
+}
+
+func TestRegister(t *testing.T) {
+	d, ok := Lookup(20001)
+	if !ok {
+		t.Fatal("Lookup() of a code registered by New found nothing")
+	}
+	want := Definition{
+		Code:       20001,
+		Message:    "余额不足",
+		HTTPStatus: http.StatusInternalServerError,
+		GRPCCode:   codes.Unknown,
+		Category:   CategoryServer,
+	}
+	if d != want {
+		t.Errorf("Lookup() = %+v, want the defaults %+v", d, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of a duplicate code did not panic")
+		}
+	}()
+	Register(Definition{Code: Code(ErrParam), Message: "参数缺失"})
+}
+
+func TestCatalog(t *testing.T) {
+	catalog := Catalog()
+	for i := 1; i < len(catalog); i++ {
+		if catalog[i-1].Code >= catalog[i].Code {
+			t.Fatalf("Catalog() not sorted by code: %d before %d", catalog[i-1].Code, catalog[i].Code)
+		}
+	}
+	if len(catalog) == 0 || catalog[0].Code != 10001 {
+		t.Errorf("Catalog() = %+v, want ErrServer first", catalog)
+	}
+}
+
+func TestMarshalCatalog(t *testing.T) {
+	md, err := MarshalCatalog("markdown")
+	if err != nil {
+		t.Fatalf("MarshalCatalog(markdown) error = %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(md)), "\n"); len(lines) != len(Catalog())+2 {
+		t.Errorf("MarshalCatalog(markdown) has %d lines, want a header and a row per code:\n%s", len(lines), md)
+	}
+	if want := "| 10002 | client | 400 Bad Request | InvalidArgument | 参数有误 |"; !strings.Contains(string(md), want) {
+		t.Errorf("MarshalCatalog(markdown) = %s, want the row %q", md, want)
+	}
+
+	js, err := MarshalCatalog("json")
+	if err != nil {
+		t.Fatalf("MarshalCatalog(json) error = %v", err)
+	}
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(js, &entries); err != nil {
+		t.Fatalf("decode MarshalCatalog(json): %v", err)
+	}
+	wantEntry := map[string]interface{}{
+		"code":       float64(10003),
+		"message":    "资源不存在",
+		"httpStatus": float64(404),
+		"grpcCode":   "NotFound",
+		"category":   "client",
+	}
+	if len(entries) < 3 || !reflect.DeepEqual(entries[2], wantEntry) {
+		t.Errorf("MarshalCatalog(json) = %s, want %v third", js, wantEntry)
+	}
+
+	if _, err := MarshalCatalog("xml"); err == nil {
+		t.Error("MarshalCatalog(xml) error = nil, want an unknown format")
+	}
+}
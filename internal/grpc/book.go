@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/changefeed"
+	"go-template/internal/errno"
+	"go-template/internal/server/model"
+	"go-template/internal/server/repository"
+	"go-template/internal/server/service"
+)
+
+// BookServer serves apiv1.BookService with the book service layer.
+type BookServer struct {
+	service service.BookService
+}
+
+// GetBook returns a book by id.
+func (b *BookServer) GetBook(ctx context.Context, req *apiv1.GetBookRequest) (*apiv1.Book, error) {
+	if req.GetId() == "" {
+		return nil, errno.ErrParam
+	}
+
+	book, err := b.service.Get(ctx, req.GetId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errno.ErrNotFound.WithError(err)
+	}
+	if err != nil {
+		return nil, errno.ErrServer.WithError(err)
+	}
+	return &apiv1.Book{Id: book.ID, Name: book.Name}, nil
+}
+
+// CreateBook creates a book.
+func (b *BookServer) CreateBook(ctx context.Context, req *apiv1.CreateBookRequest) (*apiv1.Book, error) {
+	book := req.GetBook()
+	if book.GetId() == "" || book.GetName() == "" {
+		return nil, errno.ErrParam
+	}
+	if err := b.service.Create(ctx, &model.Book{ID: book.GetId(), Name: book.GetName()}); err != nil {
+		return nil, writeError(err)
+	}
+	return book, nil
+}
+
+// UpdateBook updates a book.
+func (b *BookServer) UpdateBook(ctx context.Context, req *apiv1.UpdateBookRequest) (*apiv1.Book, error) {
+	book := req.GetBook()
+	if book.GetId() == "" || book.GetName() == "" {
+		return nil, errno.ErrParam
+	}
+	if err := b.service.Update(ctx, &model.Book{ID: book.GetId(), Name: book.GetName()}); err != nil {
+		return nil, writeError(err)
+	}
+	return book, nil
+}
+
+// DeleteBook deletes a book.
+func (b *BookServer) DeleteBook(ctx context.Context, req *apiv1.DeleteBookRequest) (*emptypb.Empty, error) {
+	if req.GetId() == "" {
+		return nil, errno.ErrParam
+	}
+	if err := b.service.Delete(ctx, req.GetId()); err != nil {
+		return nil, writeError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// NewBookServer returns a BookServer instance.
+func NewBookServer(db *sqlx.DB, feed *changefeed.Feed) *BookServer {
+	return &BookServer{
+		service: service.NewBookService(repository.NewBookRepo(db, feed)),
+	}
+}
@@ -0,0 +1,113 @@
+// Package client dials the gRPC services of this server. The connections
+// send the request ID of the context, give unary RPCs a default deadline,
+// retry the idempotent methods when the server is unavailable and record the
+// grpc_client_* metrics.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/grpc/interceptor"
+)
+
+// Config configures the connections of Dial.
+type Config struct {
+	// Timeout is the deadline of the unary RPCs called without one, retries
+	// included. Streams get no default deadline.
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts of the idempotent methods,
+	// including the first one. 1 disables the retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for each
+	// following one and randomized.
+	Backoff time.Duration
+	// TLS configures the TLS connections, they are in cleartext when nil.
+	TLS *tls.Config
+	// Registerer registers the client metrics, prometheus.DefaultRegisterer
+	// when nil.
+	Registerer prometheus.Registerer
+}
+
+// DefaultConfig returns the default configuration, in cleartext.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:     5 * time.Second,
+		MaxAttempts: 3,
+		Backoff:     100 * time.Millisecond,
+	}
+}
+
+// Dial connects to the server at target. The options are applied after
+// those of the configuration, e.g. grpc.WithBlock or a grpc.WithContextDialer
+// for tests.
+func Dial(ctx context.Context, target string, c Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	reg := c.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	metrics := interceptor.NewClientMetrics(reg)
+
+	transport := grpc.WithInsecure()
+	if c.TLS != nil {
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(c.TLS))
+	}
+	// the deadline covers the retries, each attempt is measured
+	options := []grpc.DialOption{
+		transport,
+		grpc.WithChainUnaryInterceptor(
+			unaryTimeout(c.Timeout),
+			unaryRetry(c.MaxAttempts, c.Backoff),
+			interceptor.UnaryClientRequestID(),
+			metrics.UnaryClient(),
+		),
+		grpc.WithChainStreamInterceptor(
+			interceptor.StreamClientRequestID(),
+			metrics.StreamClient(),
+		),
+	}
+
+	conn, err := grpc.DialContext(ctx, target, append(options, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", target, err)
+	}
+	return conn, nil
+}
+
+// Client holds the clients of the services of a connection.
+type Client struct {
+	Users  apiv1.UserServiceClient
+	Books  apiv1.BookServiceClient
+	Watch  apiv1.WatchServiceClient
+	Health grpc_health_v1.HealthClient
+}
+
+// New returns the clients of the services served on conn.
+func New(conn *grpc.ClientConn) *Client {
+	return &Client{
+		Users:  apiv1.NewUserServiceClient(conn),
+		Books:  apiv1.NewBookServiceClient(conn),
+		Watch:  apiv1.NewWatchServiceClient(conn),
+		Health: grpc_health_v1.NewHealthClient(conn),
+	}
+}
+
+// unaryTimeout sets the deadline of the RPCs called without one.
+func unaryTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/grpc/grpctest"
+	"go-template/internal/grpc/interceptor"
+	"go-template/internal/log"
+)
+
+// server fails the first calls with the code of failures, then answers.
+type server struct {
+	apiv1.UnimplementedUserServiceServer
+	grpc_health_v1.UnimplementedHealthServer
+
+	mu       sync.Mutex
+	failures []codes.Code
+	calls    int
+	// of the last call
+	requestID string
+	deadline  time.Duration
+}
+
+func (s *server) call(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	s.requestID = log.RequestID(ctx)
+	s.deadline = 0
+	if deadline, ok := ctx.Deadline(); ok {
+		s.deadline = time.Until(deadline)
+	}
+	if len(s.failures) > 0 {
+		code := s.failures[0]
+		s.failures = s.failures[1:]
+		return status.Error(code, code.String())
+	}
+	return nil
+}
+
+func (s *server) GetUser(ctx context.Context, req *apiv1.GetUserRequest) (*apiv1.User, error) {
+	if err := s.call(ctx); err != nil {
+		return nil, err
+	}
+	return &apiv1.User{Id: req.GetId()}, nil
+}
+
+func (s *server) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if err := s.call(ctx); err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+// dial serves srv on an in-memory listener and returns a client of it.
+func dial(t *testing.T, srv *server, c Config) *Client {
+	t.Helper()
+	s := grpc.NewServer(grpc.UnaryInterceptor(interceptor.UnaryRequestID()))
+	apiv1.RegisterUserServiceServer(s, srv)
+	grpc_health_v1.RegisterHealthServer(s, srv)
+
+	conn, err := Dial(context.Background(), "bufnet", c, grpctest.Listen(t, s))
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return New(conn)
+}
+
+func testConfig() Config {
+	c := DefaultConfig()
+	c.Backoff = time.Millisecond
+	c.Registerer = prometheus.NewRegistry()
+	return c
+}
+
+func TestDial_Retry(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    []codes.Code
+		maxAttempts int
+		health      bool
+		wantCode    codes.Code
+		wantCalls   int
+	}{
+		{
+			name:        "retried until success",
+			failures:    []codes.Code{codes.Unavailable, codes.Unavailable},
+			maxAttempts: 3,
+			wantCode:    codes.OK,
+			wantCalls:   3,
+		},
+		{
+			name:        "retried until max attempts",
+			failures:    []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.Unavailable},
+			maxAttempts: 3,
+			wantCode:    codes.Unavailable,
+			wantCalls:   3,
+		},
+		{
+			name:        "other codes are not retried",
+			failures:    []codes.Code{codes.NotFound},
+			maxAttempts: 3,
+			wantCode:    codes.NotFound,
+			wantCalls:   1,
+		},
+		{
+			name:        "retries disabled",
+			failures:    []codes.Code{codes.Unavailable},
+			maxAttempts: 1,
+			wantCode:    codes.Unavailable,
+			wantCalls:   1,
+		},
+		{
+			name:        "method not declared idempotent",
+			failures:    []codes.Code{codes.Unavailable},
+			maxAttempts: 3,
+			health:      true,
+			wantCode:    codes.Unavailable,
+			wantCalls:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &server{failures: tt.failures}
+			c := testConfig()
+			c.MaxAttempts = tt.maxAttempts
+			client := dial(t, srv, c)
+
+			var err error
+			if tt.health {
+				_, err = client.Health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			} else {
+				_, err = client.Users.GetUser(context.Background(), &apiv1.GetUserRequest{Id: "1"})
+			}
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("call code = %v, want %v", code, tt.wantCode)
+			}
+			if srv.calls != tt.wantCalls {
+				t.Errorf("server calls = %v, want %v", srv.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDial_RequestID(t *testing.T) {
+	srv := &server{}
+	client := dial(t, srv, testConfig())
+
+	ctx := log.WithRequestID(context.Background(), "abc")
+	if _, err := client.Users.GetUser(ctx, &apiv1.GetUserRequest{Id: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if srv.requestID != "abc" {
+		t.Errorf("server request ID = %q, want abc", srv.requestID)
+	}
+
+	// the metadata set by the caller wins
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "def")
+	if _, err := client.Users.GetUser(ctx, &apiv1.GetUserRequest{Id: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if srv.requestID != "def" {
+		t.Errorf("server request ID = %q, want def", srv.requestID)
+	}
+}
+
+func TestDial_Timeout(t *testing.T) {
+	srv := &server{}
+	client := dial(t, srv, testConfig())
+
+	if _, err := client.Users.GetUser(context.Background(), &apiv1.GetUserRequest{Id: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if srv.deadline <= 0 || srv.deadline > 5*time.Second {
+		t.Errorf("server deadline in %v, want the default of 5s", srv.deadline)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := client.Users.GetUser(ctx, &apiv1.GetUserRequest{Id: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if srv.deadline <= 5*time.Second {
+		t.Errorf("server deadline in %v, want the one of the caller", srv.deadline)
+	}
+}
+
+func TestDial_Metrics(t *testing.T) {
+	const method = "/gotemplate.v1.UserService/GetUser"
+
+	srv := &server{failures: []codes.Code{codes.Unavailable}}
+	c := testConfig()
+	client := dial(t, srv, c)
+	if _, err := client.Users.GetUser(context.Background(), &apiv1.GetUserRequest{Id: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// each attempt is counted
+	metrics := interceptor.NewClientMetrics(c.Registerer)
+	for _, code := range []codes.Code{codes.Unavailable, codes.OK} {
+		if count := testutil.ToFloat64(metrics.Counter.WithLabelValues(method, code.String())); count != 1 {
+			t.Errorf("grpc_client_requests_total{code=%q} = %v, want 1", code, count)
+		}
+	}
+
+	// streams are counted once their status is received
+	stream, err := client.Health.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("Health.Watch() error = %v, want Unimplemented", err)
+	}
+	stream.Recv()
+	watch := metrics.Counter.WithLabelValues("/grpc.health.v1.Health/Watch", codes.Unimplemented.String())
+	if count := testutil.ToFloat64(watch); count != 1 {
+		t.Errorf("grpc_client_requests_total{method=Watch} = %v, want 1", count)
+	}
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"go-template/internal/log"
+)
+
+// unaryRetry retries the idempotent methods failing with Unavailable, i.e.
+// when the server could not be reached or is shutting down, until
+// maxAttempts is reached or the context is done.
+func unaryRetry(maxAttempts int, backoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if maxAttempts <= 1 || !idempotent(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		delay := backoff
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if attempt == maxAttempts || status.Code(err) != codes.Unavailable {
+				return err
+			}
+
+			// full jitter spreads the retries of the clients
+			wait := time.Duration(rand.Int63n(int64(delay) + 1))
+			log.Ctx(ctx).Warn("gRPC call failed, retrying",
+				zap.String("method", method),
+				zap.Int("attempt", attempt),
+				zap.Duration("backoff", wait),
+				zap.Error(err),
+			)
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			delay *= 2
+		}
+	}
+}
+
+// idempotent tells if method, e.g. "/gotemplate.v1.UserService/GetUser", is
+// declared idempotent or without side effects by the idempotency_level
+// option of its definition.
+func idempotent(method string) bool {
+	name := protoreflect.FullName(strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", 1))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return false
+	}
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return false
+	}
+	options, ok := md.Options().(*descriptorpb.MethodOptions)
+	return ok && options.GetIdempotencyLevel() != descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN
+}
@@ -0,0 +1,22 @@
+package grpc
+
+import (
+	"database/sql"
+	"errors"
+
+	"go-template/internal/errno"
+	"go-template/internal/server/repository"
+)
+
+// writeError maps the error of a service write to an errno error: a missing
+// resource is ErrNotFound, a taken id ErrParam and the other errors
+// ErrServer, which the logging interceptor logs at error level.
+func writeError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return errno.ErrNotFound.WithError(err)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return errno.ErrParam.WithError(err)
+	}
+	return errno.ErrServer.WithError(err)
+}
@@ -0,0 +1,166 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"go-template/internal/config"
+	"go-template/internal/errno"
+	"go-template/internal/log"
+	"go-template/internal/server/model"
+)
+
+type loggerRecorder struct {
+	mockUserService
+	logger *zap.Logger
+}
+
+func (s *loggerRecorder) Get(ctx context.Context, userID string) (*model.User, error) {
+	s.logger = log.Ctx(ctx)
+	return s.mockUserService.Get(ctx, userID)
+}
+
+func TestServer_Gateway(t *testing.T) {
+	s, err := NewServer(config.GRPC{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &loggerRecorder{mockUserService: mockUserService{users: map[string]*model.User{"1": {ID: "1", Name: "A"}}}}
+	s.users = &UserServer{service: users}
+	s.books = &BookServer{service: &mockBookService{books: map[string]*model.Book{"1": {ID: "1", Name: "B"}}}}
+	gateway, err := s.Gateway(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Server.Gateway() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{
+			name:       "get user",
+			method:     http.MethodGet,
+			path:       "/v1/users/1",
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"id": "1", "name": "A"},
+		},
+		{
+			name:       "get book",
+			method:     http.MethodGet,
+			path:       "/v1/books/1",
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"id": "1", "name": "B"},
+		},
+		{
+			name:       "book not found",
+			method:     http.MethodGet,
+			path:       "/v1/books/2",
+			wantStatus: http.StatusNotFound,
+			wantBody:   map[string]interface{}{"code": float64(5), "message": "资源不存在"},
+		},
+		{
+			name:       "create book",
+			method:     http.MethodPost,
+			path:       "/v1/books",
+			body:       `{"id": "3", "name": "C"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"id": "3", "name": "C"},
+		},
+		{
+			name:       "update book",
+			method:     http.MethodPut,
+			path:       "/v1/books/3",
+			body:       `{"name": "D"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"id": "3", "name": "D"},
+		},
+		{
+			name:       "delete book",
+			method:     http.MethodDelete,
+			path:       "/v1/books/3",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "delete deleted book",
+			method:     http.MethodDelete,
+			path:       "/v1/books/3",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			path:       "/v1/authors/1",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantBody == nil {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body, err)
+			}
+			for key, want := range tt.wantBody {
+				if body[key] != want {
+					t.Errorf("body[%s] = %v, want %v", key, body[key], want)
+				}
+			}
+		})
+	}
+
+	// the services get the context of the HTTP request, with the logger of
+	// the request ID middleware
+	logger := zap.NewNop().With(zap.String("X-Request-ID", "abc"))
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/1", nil)
+	req = req.WithContext(log.NewContext(req.Context(), logger))
+	gateway.ServeHTTP(httptest.NewRecorder(), req)
+	if users.logger != logger {
+		t.Errorf("the service did not get the logger of the request")
+	}
+}
+
+func TestServer_GatewayErrors(t *testing.T) {
+	s, err := NewServer(config.GRPC{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.users = &UserServer{service: &mockUserService{}}
+	s.books = &BookServer{service: &mockBookService{}}
+
+	var handled error
+	gateway, err := s.Gateway(context.Background(), func(r *http.Request, err error) bool {
+		handled = err
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Server.Gateway() error = %v", err)
+	}
+
+	// the errors of the services are handed over as is, with their detail
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/books/2", nil))
+	if errno.Code(handled) != 10003 || errno.Detail(handled) == "" {
+		t.Errorf("handled error = %#v, want errno.ErrNotFound with its cause", handled)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want it left to the error handler", w.Body)
+	}
+}
@@ -0,0 +1,37 @@
+// Package grpctest serves gRPC servers on in-memory connections for tests.
+package grpctest
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Listen serves s on an in-memory listener and returns the option dialing
+// it, whatever the target. s is stopped at the end of the test.
+func Listen(t testing.TB, s *grpc.Server) grpc.DialOption {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.Dial()
+	})
+}
+
+// Dial serves s like Listen and returns an insecure client connection to
+// it, closed at the end of the test.
+func Dial(t testing.TB, s *grpc.Server, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	opts = append([]grpc.DialOption{Listen(t, s), grpc.WithInsecure()}, opts...)
+	conn, err := grpc.DialContext(context.Background(), "bufnet", opts...)
+	if err != nil {
+		t.Fatalf("Dial error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
@@ -0,0 +1,173 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/errno"
+	"go-template/internal/grpc/grpctest"
+)
+
+type userServer struct {
+	apiv1.UnimplementedUserServiceServer
+	get func(ctx context.Context) (*apiv1.User, error)
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *apiv1.GetUserRequest) (*apiv1.User, error) {
+	return s.get(ctx)
+}
+
+func dial(t *testing.T, metrics *Metrics, users apiv1.UserServiceServer) *grpc.ClientConn {
+	t.Helper()
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryRequestID(), UnaryLogger(), metrics.Unary(), UnaryLocale(), UnaryStatus(), UnaryRecovery()),
+		grpc.ChainStreamInterceptor(StreamRequestID(), StreamLogger(), metrics.Stream(), StreamLocale(), StreamStatus(), StreamRecovery()),
+	)
+	apiv1.RegisterUserServiceServer(s, users)
+	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
+	return grpctest.Dial(t, s)
+}
+
+func TestInterceptors_Unary(t *testing.T) {
+	const method = "/gotemplate.v1.UserService/GetUser"
+
+	tests := []struct {
+		name          string
+		requestID     string
+		get           func(ctx context.Context) (*apiv1.User, error)
+		wantCode      codes.Code
+		wantRequestID string
+	}{
+		{
+			name:          "request id is propagated",
+			requestID:     "abc",
+			get:           func(ctx context.Context) (*apiv1.User, error) { return &apiv1.User{Id: "1"}, nil },
+			wantCode:      codes.OK,
+			wantRequestID: "abc",
+		},
+		{
+			name:     "request id is generated",
+			get:      func(ctx context.Context) (*apiv1.User, error) { return &apiv1.User{Id: "1"}, nil },
+			wantCode: codes.OK,
+		},
+		{
+			name:      "error",
+			requestID: "def",
+			get: func(ctx context.Context) (*apiv1.User, error) {
+				return nil, status.Error(codes.NotFound, "not found")
+			},
+			wantCode:      codes.NotFound,
+			wantRequestID: "def",
+		},
+		{
+			name:      "wrapped errno error",
+			requestID: "jkl",
+			get: func(ctx context.Context) (*apiv1.User, error) {
+				return nil, fmt.Errorf("get user 1: %w", errno.ErrNotFound)
+			},
+			wantCode:      codes.NotFound,
+			wantRequestID: "jkl",
+		},
+		{
+			name:      "panic is recovered",
+			requestID: "ghi",
+			get: func(ctx context.Context) (*apiv1.User, error) {
+				panic("boom")
+			},
+			wantCode:      codes.Internal,
+			wantRequestID: "ghi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := NewMetrics(prometheus.NewRegistry())
+			conn := dial(t, metrics, &userServer{get: tt.get})
+
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, requestIDKey, tt.requestID)
+			}
+			var header metadata.MD
+			_, err := apiv1.NewUserServiceClient(conn).GetUser(ctx, &apiv1.GetUserRequest{Id: "1"}, grpc.Header(&header))
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("GetUser() code = %v, want %v", code, tt.wantCode)
+			}
+
+			got := header.Get(requestIDKey)
+			if len(got) != 1 || got[0] == "" {
+				t.Fatalf("header %s = %v, want one request id", requestIDKey, got)
+			}
+			if tt.wantRequestID != "" && got[0] != tt.wantRequestID {
+				t.Errorf("header %s = %v, want %v", requestIDKey, got[0], tt.wantRequestID)
+			}
+
+			count := testutil.ToFloat64(metrics.Counter.WithLabelValues(method, tt.wantCode.String()))
+			if count != 1 {
+				t.Errorf("requests_total{code=%q} = %v, want 1", tt.wantCode, count)
+			}
+		})
+	}
+}
+
+func TestInterceptors_Stream(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	conn := dial(t, metrics, &userServer{})
+
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "abc"))
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(requestIDKey); len(got) != 1 || got[0] != "abc" {
+		t.Errorf("header %s = %v, want abc", requestIDKey, got)
+	}
+	cancel()
+}
+
+func TestNewMetrics_Reuse(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m1, m2 := NewMetrics(reg), NewMetrics(reg)
+	if m1.Counter != m2.Counter || m1.Histogram != m2.Histogram {
+		t.Errorf("NewMetrics() registered the metrics twice")
+	}
+}
+
+func TestWithStatus(t *testing.T) {
+	wrapped := fmt.Errorf("get user 1: %w", errno.ErrNotFound.WithError(errors.New("sql: no rows")))
+	err := withStatus(wrapped)
+
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.NotFound {
+		t.Errorf("status.FromError(withStatus()) = %v, %v, want NotFound", st, ok)
+	}
+	if got := errno.Code(errno.FromError(st.Err())); got != errno.Code(errno.ErrNotFound) {
+		t.Errorf("errno code of the status = %d, want %d", got, errno.Code(errno.ErrNotFound))
+	}
+	if !errors.Is(err, errno.ErrNotFound) || err.Error() != wrapped.Error() {
+		t.Errorf("withStatus() = %v, want the wrapped chain kept", err)
+	}
+
+	plain := errors.New("boom")
+	if err := withStatus(plain); err != plain {
+		t.Errorf("withStatus(%v) = %v, want it unchanged", plain, err)
+	}
+}
@@ -0,0 +1,43 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"go-template/internal/errno"
+)
+
+// languageKey is the metadata key of the languages accepted by the client,
+// with the syntax of the Accept-Language header.
+const languageKey = "accept-language"
+
+// UnaryLocale is an interceptor that translates the message of the errno
+// errors returned by handlers into the language of the accept-language
+// metadata, see errno.Localize.
+func UnaryLocale() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, localize(ctx, err)
+	}
+}
+
+// StreamLocale is the stream counterpart of UnaryLocale.
+func StreamLocale() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return localize(ss.Context(), handler(srv, ss))
+	}
+}
+
+func localize(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(languageKey); len(values) > 0 {
+		return errno.Localize(err, strings.Join(values, ","))
+	}
+	return err
+}
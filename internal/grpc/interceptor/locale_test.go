@@ -0,0 +1,48 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/errno"
+)
+
+func TestLocale(t *testing.T) {
+	get := func(ctx context.Context) (*apiv1.User, error) {
+		return nil, errno.ErrNotFound.WithError(errors.New("sql: no rows"))
+	}
+	conn := dial(t, NewMetrics(prometheus.NewRegistry()), &userServer{get: get})
+	client := apiv1.NewUserServiceClient(conn)
+
+	tests := []struct {
+		name        string
+		language    string
+		wantMessage string
+	}{
+		{name: "english", language: "en-US,en;q=0.9", wantMessage: "Resource not found"},
+		{name: "default language", wantMessage: "资源不存在"},
+		{name: "language without catalog", language: "fr", wantMessage: "资源不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.language != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, languageKey, tt.language)
+			}
+			_, err := client.GetUser(ctx, &apiv1.GetUserRequest{Id: "1"})
+			if st := status.Convert(err); st.Code() != codes.NotFound || st.Message() != tt.wantMessage {
+				t.Errorf("GetUser() status = %v %q, want NotFound %q", st.Code(), st.Message(), tt.wantMessage)
+			}
+			if e := errno.FromError(err); errno.Code(e) != 10003 || e.Error() != tt.wantMessage {
+				t.Errorf("FromError() = %d %q, want 10003 %q", errno.Code(e), e.Error(), tt.wantMessage)
+			}
+		})
+	}
+}
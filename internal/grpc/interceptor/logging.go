@@ -0,0 +1,69 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"go-template/internal/errno"
+	"go-template/internal/log"
+)
+
+// UnaryLogger is an interceptor that logs each RPC with the logger of its
+// context, like the HTTP logging middleware. RPCs failing with an errno
+// error of CategoryServer are logged at error level.
+func UnaryLogger() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		logRequest(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		logResponse(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamLogger is the stream counterpart of UnaryLogger.
+func StreamLogger() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		logRequest(ss.Context(), info.FullMethod)
+		err := handler(srv, ss)
+		logResponse(ss.Context(), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logRequest(ctx context.Context, method string) {
+	fields := []zap.Field{zap.String("method", method)}
+	if p, ok := peer.FromContext(ctx); ok {
+		fields = append(fields, zap.String("remote", p.Addr.String()))
+	}
+	log.Ctx(ctx).Debug("request", fields...)
+}
+
+func logResponse(ctx context.Context, method string, start time.Time, err error) {
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	}
+	if err == nil {
+		log.Ctx(ctx).Debug("response", fields...)
+		return
+	}
+	fields = append(fields, zap.Error(err))
+	// server errors are failures to look at, with the stack recorded by
+	// errno.WithError if any
+	if d, ok := errno.Lookup(errno.Code(err)); ok && d.Category == errno.CategoryServer {
+		if stack := errno.Stack(err); stack != "" {
+			fields = append(fields, zap.String("errorStack", stack))
+		}
+		log.Ctx(ctx).Error("response", fields...)
+		return
+	}
+	log.Ctx(ctx).Debug("response", fields...)
+}
@@ -0,0 +1,71 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"go-template/internal/errno"
+	"go-template/internal/log"
+)
+
+func TestLogResponse(t *testing.T) {
+	errno.CaptureStacks(true)
+	defer errno.CaptureStacks(false)
+
+	tests := []struct {
+		name      string
+		err       error
+		wantLevel zapcore.Level
+		wantStack bool
+	}{
+		{name: "ok", wantLevel: zapcore.DebugLevel},
+		{
+			name:      "server error",
+			err:       fmt.Errorf("list books: %w", errno.ErrServer.WithError(errors.New("connection refused"))),
+			wantLevel: zapcore.ErrorLevel,
+			wantStack: true,
+		},
+		{
+			name:      "server error without stack",
+			err:       errno.ErrUnavailable,
+			wantLevel: zapcore.ErrorLevel,
+		},
+		{
+			name:      "client error",
+			err:       errno.ErrParam.WithError(errors.New("id is empty")),
+			wantLevel: zapcore.DebugLevel,
+		},
+		{
+			name:      "not an errno error",
+			err:       errors.New("context canceled"),
+			wantLevel: zapcore.DebugLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			ctx := log.NewContext(context.Background(), zap.New(core))
+			logResponse(ctx, "/gotemplate.v1.BookService/GetBook", time.Now(), tt.err)
+
+			entries := logs.AllUntimed()
+			if len(entries) != 1 {
+				t.Fatalf("logged %d entries, want 1", len(entries))
+			}
+			if entries[0].Level != tt.wantLevel {
+				t.Errorf("level = %v, want %v", entries[0].Level, tt.wantLevel)
+			}
+			stack, ok := entries[0].ContextMap()["errorStack"].(string)
+			if ok != tt.wantStack || (ok && !strings.Contains(stack, "logging_test.go:")) {
+				t.Errorf("errorStack = %q, want a stack %v", stack, tt.wantStack)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics holds the Prometheus metrics of the RPCs, labelled by full method
+// name and status code.
+type Metrics struct {
+	Histogram *prometheus.HistogramVec
+	Counter   *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics and registers them with reg. Metrics
+// already registered, e.g. by another server of the process, are reused.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	return newMetrics(reg, "grpc", "Seconds spent serving gRPC requests", "The total number of gRPC requests.")
+}
+
+// NewClientMetrics creates the metrics of the RPCs sent by clients,
+// grpc_client_request_duration_seconds and grpc_client_requests_total, and
+// registers them with reg like NewMetrics.
+func NewClientMetrics(reg prometheus.Registerer) *Metrics {
+	return newMetrics(reg, "grpc_client", "Seconds spent waiting for gRPC requests", "The total number of gRPC requests sent.")
+}
+
+func newMetrics(reg prometheus.Registerer, subsystem, histogramHelp, counterHelp string) *Metrics {
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: subsystem,
+		Name:      "request_duration_seconds",
+		Help:      histogramHelp,
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      counterHelp,
+		},
+		[]string{"method", "code"},
+	)
+
+	return &Metrics{
+		Histogram: register(reg, histogram).(*prometheus.HistogramVec),
+		Counter:   register(reg, counter).(*prometheus.CounterVec),
+	}
+}
+
+func register(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+// Unary returns an interceptor observing unary RPCs.
+func (m *Metrics) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		begin := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe(info.FullMethod, begin, err)
+		return resp, err
+	}
+}
+
+// Stream returns an interceptor observing stream RPCs.
+func (m *Metrics) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		begin := time.Now()
+		err := handler(srv, ss)
+		m.observe(info.FullMethod, begin, err)
+		return err
+	}
+}
+
+// UnaryClient returns a client interceptor observing unary RPCs.
+func (m *Metrics) UnaryClient() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		begin := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.observe(method, begin, err)
+		return err
+	}
+}
+
+// StreamClient returns a client interceptor observing stream RPCs, from their
+// start until the client receives their status.
+func (m *Metrics) StreamClient() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		begin := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			m.observe(method, begin, err)
+			return nil, err
+		}
+		return &observedStream{ClientStream: cs, observe: func(err error) { m.observe(method, begin, err) }}, nil
+	}
+}
+
+// observedStream observes a stream when RecvMsg returns its status.
+type observedStream struct {
+	grpc.ClientStream
+	once    sync.Once
+	observe func(err error)
+}
+
+func (s *observedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		s.once.Do(func() { s.observe(nil) })
+	} else if err != nil {
+		s.once.Do(func() { s.observe(err) })
+	}
+	return err
+}
+
+func (m *Metrics) observe(method string, begin time.Time, err error) {
+	code := status.Code(err).String()
+	m.Histogram.WithLabelValues(method, code).Observe(time.Since(begin).Seconds())
+	m.Counter.WithLabelValues(method, code).Inc()
+}
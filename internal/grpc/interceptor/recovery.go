@@ -0,0 +1,40 @@
+package interceptor
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go-template/internal/log"
+)
+
+// UnaryRecovery is an interceptor that recovers from panics in handlers. The
+// panic is logged with its stack and the RPC fails with codes.Internal.
+func UnaryRecovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverPanic(ctx, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery is the stream counterpart of UnaryRecovery.
+func StreamRecovery() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(ss.Context(), info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(ctx context.Context, method string, err *error) {
+	if r := recover(); r != nil {
+		log.Ctx(ctx).Error("panic recovered",
+			zap.String("method", method),
+			zap.Any("panic", r),
+			zap.Stack("stack"),
+		)
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
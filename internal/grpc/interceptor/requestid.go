@@ -0,0 +1,74 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"go-template/internal/log"
+)
+
+// requestIDKey is the metadata key of the request ID, metadata keys are lower
+// case.
+const requestIDKey = "x-request-id"
+
+// UnaryRequestID is an interceptor that injects a logger with the request ID
+// into the context of each RPC, see log.Ctx. The ID is read from the
+// x-request-id metadata or generated, and sent back in the response header.
+func UnaryRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+// StreamRequestID is the stream counterpart of UnaryRequestID.
+func StreamRequestID() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, withContext(ss, withRequestID(ss.Context())))
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	// the header is sent with the first response message
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+	return log.WithRequestID(ctx, requestID)
+}
+
+// UnaryClientRequestID is a client interceptor that sends the request ID of
+// the context, see log.RequestID, in the x-request-id metadata. The RPCs made
+// while serving a request are then logged with the ID of the request.
+func UnaryClientRequestID() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientRequestID is the stream counterpart of UnaryClientRequestID.
+func StreamClientRequestID() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+	}
+}
+
+func outgoingRequestID(ctx context.Context) context.Context {
+	requestID := log.RequestID(ctx)
+	if requestID == "" {
+		return ctx
+	}
+	// an ID set by the caller wins
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(requestIDKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, requestIDKey, requestID)
+}
@@ -0,0 +1,62 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// grpcStatus is implemented by the errors carrying a gRPC status, such as
+// the errno errors and the errors of the status package.
+type grpcStatus interface {
+	GRPCStatus() *status.Status
+}
+
+// UnaryStatus is an interceptor that keeps the status of the errors wrapped
+// by handlers, e.g. with fmt.Errorf("...: %w", errno.ErrNotFound). grpc-go
+// only asks the returned error itself for its status and would otherwise
+// fail the RPC with codes.Unknown.
+func UnaryStatus() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, withStatus(err)
+	}
+}
+
+// StreamStatus is the stream counterpart of UnaryStatus.
+func StreamStatus() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return withStatus(handler(srv, ss))
+	}
+}
+
+// withStatus returns err with the status of the first error of its chain
+// carrying one. The chain is kept for the logging interceptor.
+func withStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(grpcStatus); ok {
+		return err
+	}
+	var s grpcStatus
+	if !errors.As(err, &s) {
+		return err
+	}
+	return &statusError{err: err, status: s.GRPCStatus()}
+}
+
+// statusError is an error returned by withStatus.
+type statusError struct {
+	err    error
+	status *status.Status
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+func (e *statusError) Unwrap() error { return e.err }
+
+// GRPCStatus returns the status of the wrapped error.
+func (e *statusError) GRPCStatus() *status.Status { return e.status }
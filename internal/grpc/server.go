@@ -1,47 +1,187 @@
 package grpc
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"net/http"
 
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/changefeed"
+	"go-template/internal/config"
+	"go-template/internal/grpc/interceptor"
+	"go-template/internal/server/cache"
 )
 
+// Server is a gRPC server, served on a port of its own or on the port of the
+// HTTP server through ServeHTTP.
 type Server struct {
 	logger *zap.Logger
-	config *Config
-}
-
-type Config struct {
-	Port        int    `mapstructure:"grpc-port"`
-	ServiceName string `mapstructure:"grpc-service-name"`
+	config config.GRPC
+	server *grpc.Server
+	health *health.Server
+	users  *UserServer
+	books  *BookServer
+	watch  *WatchServer
 }
 
-func NewServer(config *Config, logger *zap.Logger) (*Server, error) {
+// NewServer returns a gRPC server with the health and reflection services.
+// RPCs get a request ID, a logger, panic recovery, metrics and localized
+// error messages, like the HTTP requests.
+func NewServer(config config.GRPC, logger *zap.Logger) (*Server, error) {
+	metrics := interceptor.NewMetrics(prometheus.DefaultRegisterer)
 	srv := &Server{
 		logger: logger,
 		config: config,
+		// RequestID must be the first, Recovery the last so that panics are
+		// logged and measured as failed RPCs, and Status right before it so
+		// that the errors wrapped by handlers keep their status
+		server: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(
+				interceptor.UnaryRequestID(),
+				interceptor.UnaryLogger(),
+				metrics.Unary(),
+				interceptor.UnaryLocale(),
+				interceptor.UnaryStatus(),
+				interceptor.UnaryRecovery(),
+			),
+			grpc.ChainStreamInterceptor(
+				interceptor.StreamRequestID(),
+				interceptor.StreamLogger(),
+				metrics.Stream(),
+				interceptor.StreamLocale(),
+				interceptor.StreamStatus(),
+				interceptor.StreamRecovery(),
+			),
+		),
 	}
+
+	// not serving until the dependencies are checked, see SetServing
+	srv.health = health.NewServer()
+	srv.SetServing(false)
+	reflection.Register(srv.server)
+	grpc_health_v1.RegisterHealthServer(srv.server, srv.health)
 	return srv, nil
 }
 
-func (s *Server) ListenAndServe() {
+// SetServing sets the status reported by the health service, for the
+// server as a whole and for the configured service name. Clients watching
+// the status are notified of the change.
+func (s *Server) SetServing(serving bool) {
+	status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", status)
+	if s.config.ServiceName != "" {
+		s.health.SetServingStatus(s.config.ServiceName, status)
+	}
+}
+
+// ShutdownHealth reports NOT_SERVING from now on, SetServing has no effect
+// anymore. It lets load balancers stop sending RPCs before the server
+// drains.
+func (s *Server) ShutdownHealth() {
+	s.health.Shutdown()
+}
+
+// RegisterServices registers the user, book and watch services. It must be
+// called before the server serves.
+func (s *Server) RegisterServices(pool cache.Pool, db *sqlx.DB, feed *changefeed.Feed) {
+	s.users, s.books = NewUserServer(pool, db, feed), NewBookServer(db, feed)
+	s.watch = NewWatchServer(feed)
+	apiv1.RegisterUserServiceServer(s.server, s.users)
+	apiv1.RegisterBookServiceServer(s.server, s.books)
+	apiv1.RegisterWatchServiceServer(s.server, s.watch)
+}
+
+// ErrorHandler handles the errors of the gateway requests, e.g. hands them to
+// the error rendering of the HTTP server. It returns false to let the gateway
+// render the error.
+type ErrorHandler func(r *http.Request, err error) bool
+
+// Gateway returns a handler serving the registered services as JSON over
+// HTTP, as mapped by the google.api.http options of api/proto. Requests call
+// the services in process: they get the context of the HTTP request, and the
+// HTTP middlewares rather than the gRPC interceptors. The errors are passed
+// to onError, if not nil.
+func (s *Server) Gateway(ctx context.Context, onError ErrorHandler) (http.Handler, error) {
+	var opts []runtime.ServeMuxOption
+	if onError != nil {
+		opts = append(opts, runtime.WithProtoErrorHandler(
+			func(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+				if !onError(r, err) {
+					runtime.DefaultHTTPError(ctx, mux, m, w, r, err)
+				}
+			},
+		))
+	}
+	mux := runtime.NewServeMux(opts...)
+	if err := apiv1.RegisterUserServiceHandlerServer(ctx, mux, s.users); err != nil {
+		return nil, err
+	}
+	if err := apiv1.RegisterBookServiceHandlerServer(ctx, mux, s.books); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// Listen listens on the configured port.
+func (s *Server) Listen() (net.Listener, error) {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%v", s.config.Port))
 	if err != nil {
-		s.logger.Fatal("failed to listen", zap.Int("port", s.config.Port))
+		return nil, fmt.Errorf("failed to listen on port %d: %w", s.config.Port, err)
 	}
+	return listener, nil
+}
 
-	srv := grpc.NewServer()
-	server := health.NewServer()
-	reflection.Register(srv)
-	grpc_health_v1.RegisterHealthServer(srv, server)
-	server.SetServingStatus(s.config.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+// Serve serves the connections of listener until the server is stopped. It
+// returns nil once the server is stopped.
+func (s *Server) Serve(listener net.Listener) error {
+	if err := s.server.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP serves a gRPC request received by a HTTP/2 server, which lets
+// gRPC share a port with other HTTP handlers. The server must then be
+// stopped with Stop, GracefulStop does not support such requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.server.ServeHTTP(w, r)
+}
 
-	if err := srv.Serve(listener); err != nil {
-		s.logger.Fatal("failed to serve", zap.Error(err))
+// Shutdown stops the server gracefully: it stops accepting connections and
+// waits for the pending RPCs to finish. If ctx is done first, the remaining
+// RPCs are cancelled and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.server.GracefulStop()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.logger.Warn("gRPC server graceful stop timed out, cancelling the pending RPCs")
+		s.server.Stop()
+		<-done
+		return ctx.Err()
 	}
 }
+
+// Stop closes the connections and cancels the pending RPCs.
+func (s *Server) Stop() {
+	s.server.Stop()
+}
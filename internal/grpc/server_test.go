@@ -0,0 +1,137 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"go-template/internal/config"
+)
+
+func TestServer_Shutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  bool
+		wantErr bool
+	}{
+		{
+			name: "graceful",
+		},
+		{
+			name:    "pending stream is cancelled after the timeout",
+			stream:  true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewServer(config.GRPC{ServiceName: "test"}, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.SetServing(true)
+			served := make(chan error, 1)
+			go func() { served <- s.Serve(listener) }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+			if err != nil {
+				t.Fatalf("Dial error = %v", err)
+			}
+			defer conn.Close()
+
+			client := grpc_health_v1.NewHealthClient(conn)
+			res, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "test"})
+			if err != nil || res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+				t.Fatalf("Health.Check = %v, %v, want SERVING", res, err)
+			}
+			if tt.stream {
+				stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "test"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := stream.Recv(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancelShutdown()
+			if err := s.Shutdown(shutdownCtx); (err != nil) != tt.wantErr {
+				t.Errorf("Server.Shutdown() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := <-served; err != nil {
+				t.Errorf("Server.Serve() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestServer_Health(t *testing.T) {
+	s, err := NewServer(config.GRPC{ServiceName: "test"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(listener)
+	defer s.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Dial error = %v", err)
+	}
+	defer conn.Close()
+
+	for _, service := range []string{"", "test"} {
+		s.SetServing(false)
+		stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := func(want grpc_health_v1.HealthCheckResponse_ServingStatus) {
+			t.Helper()
+			res, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("Watch(%q) error = %v", service, err)
+			}
+			if res.Status != want {
+				t.Errorf("Watch(%q) status = %v, want %v", service, res.Status, want)
+			}
+		}
+
+		// the transitions are streamed, repeated statuses are not
+		expect(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		s.SetServing(true)
+		expect(grpc_health_v1.HealthCheckResponse_SERVING)
+		s.SetServing(true)
+		s.SetServing(false)
+		expect(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		s.SetServing(true)
+		expect(grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+
+	s.ShutdownHealth()
+	s.SetServing(true)
+	res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Check() after shutdown status = %v, want NOT_SERVING", res.Status)
+	}
+}
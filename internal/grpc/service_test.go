@@ -0,0 +1,294 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/grpc/grpctest"
+	"go-template/internal/server/model"
+	"go-template/internal/server/repository"
+)
+
+type mockUserService struct {
+	users map[string]*model.User
+	err   error
+}
+
+func (s *mockUserService) Get(ctx context.Context, userID string) (*model.User, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if user, ok := s.users[userID]; ok {
+		return user, nil
+	}
+	return &model.User{}, nil
+}
+
+func (s *mockUserService) Create(ctx context.Context, user *model.User) error {
+	if s.err != nil {
+		return s.err
+	}
+	if _, ok := s.users[user.ID]; ok {
+		return fmt.Errorf("Create User failed. userId: %v, error: %w", user.ID, repository.ErrAlreadyExists)
+	}
+	if s.users == nil {
+		s.users = make(map[string]*model.User)
+	}
+	s.users[user.ID] = user
+	return nil
+}
+
+func (s *mockUserService) Update(ctx context.Context, user *model.User) error {
+	if s.err != nil {
+		return s.err
+	}
+	if _, ok := s.users[user.ID]; !ok {
+		return fmt.Errorf("Update User failed. userId: %v, error: %w", user.ID, sql.ErrNoRows)
+	}
+	s.users[user.ID] = user
+	return nil
+}
+
+func (s *mockUserService) Delete(ctx context.Context, userID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("Delete User failed. userId: %v, error: %w", userID, sql.ErrNoRows)
+	}
+	delete(s.users, userID)
+	return nil
+}
+
+type mockBookService struct {
+	books map[string]*model.Book
+}
+
+func (s *mockBookService) Get(ctx context.Context, bookID string) (*model.Book, error) {
+	if book, ok := s.books[bookID]; ok {
+		return book, nil
+	}
+	return nil, fmt.Errorf("Get Book failed. bookId: %v, error: %w", bookID, sql.ErrNoRows)
+}
+
+func (s *mockBookService) Create(ctx context.Context, book *model.Book) error {
+	if _, ok := s.books[book.ID]; ok {
+		return fmt.Errorf("Create Book failed. bookId: %v, error: %w", book.ID, repository.ErrAlreadyExists)
+	}
+	if s.books == nil {
+		s.books = make(map[string]*model.Book)
+	}
+	s.books[book.ID] = book
+	return nil
+}
+
+func (s *mockBookService) Update(ctx context.Context, book *model.Book) error {
+	if _, ok := s.books[book.ID]; !ok {
+		return fmt.Errorf("Update Book failed. bookId: %v, error: %w", book.ID, sql.ErrNoRows)
+	}
+	s.books[book.ID] = book
+	return nil
+}
+
+func (s *mockBookService) Delete(ctx context.Context, bookID string) error {
+	if _, ok := s.books[bookID]; !ok {
+		return fmt.Errorf("Delete Book failed. bookId: %v, error: %w", bookID, sql.ErrNoRows)
+	}
+	delete(s.books, bookID)
+	return nil
+}
+
+// dial serves the services registered by register and returns a client
+// connection to them.
+func dial(t *testing.T, register func(s *grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+	s := grpc.NewServer()
+	register(s)
+	return grpctest.Dial(t, s)
+}
+
+func TestUserServer_GetUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  *mockUserService
+		id       string
+		want     *apiv1.User
+		wantCode codes.Code
+	}{
+		{
+			name:     "found",
+			service:  &mockUserService{users: map[string]*model.User{"1": {ID: "1", Name: "A"}}},
+			id:       "1",
+			want:     &apiv1.User{Id: "1", Name: "A"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "not found",
+			service:  &mockUserService{},
+			id:       "2",
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "missing id",
+			service:  &mockUserService{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "service failure",
+			service:  &mockUserService{err: errors.New("connection refused")},
+			id:       "1",
+			wantCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dial(t, func(s *grpc.Server) {
+				apiv1.RegisterUserServiceServer(s, &UserServer{service: tt.service})
+			})
+
+			got, err := apiv1.NewUserServiceClient(conn).GetUser(context.Background(), &apiv1.GetUserRequest{Id: tt.id})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("UserService.GetUser() code = %v, want %v (error %v)", code, tt.wantCode, err)
+			}
+			if err == nil && (got.GetId() != tt.want.GetId() || got.GetName() != tt.want.GetName()) {
+				t.Errorf("UserService.GetUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookServer_GetBook(t *testing.T) {
+	service := &mockBookService{books: map[string]*model.Book{"1": {ID: "1", Name: "A"}}}
+	conn := dial(t, func(s *grpc.Server) {
+		apiv1.RegisterBookServiceServer(s, &BookServer{service: service})
+	})
+	client := apiv1.NewBookServiceClient(conn)
+
+	tests := []struct {
+		name     string
+		id       string
+		want     *apiv1.Book
+		wantCode codes.Code
+	}{
+		{
+			name:     "found",
+			id:       "1",
+			want:     &apiv1.Book{Id: "1", Name: "A"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "not found",
+			id:       "2",
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "missing id",
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.GetBook(context.Background(), &apiv1.GetBookRequest{Id: tt.id})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("BookService.GetBook() code = %v, want %v (error %v)", code, tt.wantCode, err)
+			}
+			if err == nil && (got.GetId() != tt.want.GetId() || got.GetName() != tt.want.GetName()) {
+				t.Errorf("BookService.GetBook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserServer_Write(t *testing.T) {
+	service := &mockUserService{}
+	conn := dial(t, func(s *grpc.Server) {
+		apiv1.RegisterUserServiceServer(s, &UserServer{service: service})
+	})
+	client := apiv1.NewUserServiceClient(conn)
+	ctx := context.Background()
+
+	steps := []struct {
+		name     string
+		call     func() error
+		wantCode codes.Code
+	}{
+		{
+			name: "create",
+			call: func() error {
+				_, err := client.CreateUser(ctx, &apiv1.CreateUserRequest{User: &apiv1.User{Id: "1", Name: "A"}})
+				return err
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "create a taken id",
+			call: func() error {
+				_, err := client.CreateUser(ctx, &apiv1.CreateUserRequest{User: &apiv1.User{Id: "1", Name: "B"}})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "create without name",
+			call: func() error {
+				_, err := client.CreateUser(ctx, &apiv1.CreateUserRequest{User: &apiv1.User{Id: "2"}})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "update",
+			call: func() error {
+				_, err := client.UpdateUser(ctx, &apiv1.UpdateUserRequest{User: &apiv1.User{Id: "1", Name: "B"}})
+				return err
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "update a missing user",
+			call: func() error {
+				_, err := client.UpdateUser(ctx, &apiv1.UpdateUserRequest{User: &apiv1.User{Id: "2", Name: "B"}})
+				return err
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "delete",
+			call: func() error {
+				_, err := client.DeleteUser(ctx, &apiv1.DeleteUserRequest{Id: "1"})
+				return err
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "delete a missing user",
+			call: func() error {
+				_, err := client.DeleteUser(ctx, &apiv1.DeleteUserRequest{Id: "1"})
+				return err
+			},
+			wantCode: codes.NotFound,
+		},
+	}
+	for _, step := range steps {
+		if code := status.Code(step.call()); code != step.wantCode {
+			t.Errorf("%s: code = %v, want %v", step.name, code, step.wantCode)
+		}
+		if step.name == "update" && service.users["1"].Name != "B" {
+			t.Errorf("%s: user = %v, want the new name", step.name, service.users["1"])
+		}
+	}
+
+	service.err = errors.New("connection refused")
+	_, err := client.CreateUser(ctx, &apiv1.CreateUserRequest{User: &apiv1.User{Id: "3", Name: "C"}})
+	if code := status.Code(err); code != codes.Internal {
+		t.Errorf("create with a service failure: code = %v, want Internal", code)
+	}
+}
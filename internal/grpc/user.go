@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/changefeed"
+	"go-template/internal/errno"
+	"go-template/internal/server/cache"
+	"go-template/internal/server/model"
+	"go-template/internal/server/repository"
+	"go-template/internal/server/service"
+)
+
+// UserServer serves apiv1.UserService with the user service layer shared
+// with the HTTP API.
+type UserServer struct {
+	service service.UserService
+}
+
+// GetUser returns a user by id.
+func (u *UserServer) GetUser(ctx context.Context, req *apiv1.GetUserRequest) (*apiv1.User, error) {
+	if req.GetId() == "" {
+		return nil, errno.ErrParam
+	}
+
+	user, err := u.service.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, errno.ErrServer.WithError(err)
+	}
+	// a missing user is cached as an empty hash
+	if user.ID == "" {
+		return nil, errno.ErrNotFound.WithError(fmt.Errorf("user %s not found", req.GetId()))
+	}
+	return &apiv1.User{Id: user.ID, Name: user.Name}, nil
+}
+
+// CreateUser creates a user.
+func (u *UserServer) CreateUser(ctx context.Context, req *apiv1.CreateUserRequest) (*apiv1.User, error) {
+	user := req.GetUser()
+	if user.GetId() == "" || user.GetName() == "" {
+		return nil, errno.ErrParam
+	}
+	if err := u.service.Create(ctx, &model.User{ID: user.GetId(), Name: user.GetName()}); err != nil {
+		return nil, writeError(err)
+	}
+	return user, nil
+}
+
+// UpdateUser updates a user.
+func (u *UserServer) UpdateUser(ctx context.Context, req *apiv1.UpdateUserRequest) (*apiv1.User, error) {
+	user := req.GetUser()
+	if user.GetId() == "" || user.GetName() == "" {
+		return nil, errno.ErrParam
+	}
+	if err := u.service.Update(ctx, &model.User{ID: user.GetId(), Name: user.GetName()}); err != nil {
+		return nil, writeError(err)
+	}
+	return user, nil
+}
+
+// DeleteUser deletes a user.
+func (u *UserServer) DeleteUser(ctx context.Context, req *apiv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if req.GetId() == "" {
+		return nil, errno.ErrParam
+	}
+	if err := u.service.Delete(ctx, req.GetId()); err != nil {
+		return nil, writeError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// NewUserServer returns an UserServer instance.
+func NewUserServer(pool cache.Pool, db *sqlx.DB, feed *changefeed.Feed) *UserServer {
+	repo := repository.NewUserRepo(db, feed)
+	cache := cache.NewUserCache(pool)
+	return &UserServer{
+		service: service.NewUserService(repo, cache),
+	}
+}
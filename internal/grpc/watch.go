@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/metadata"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/changefeed"
+	"go-template/internal/errno"
+	"go-template/internal/server/model"
+)
+
+var (
+	kinds = map[apiv1.ResourceKind]changefeed.Kind{
+		apiv1.ResourceKind_RESOURCE_KIND_USER: changefeed.KindUser,
+		apiv1.ResourceKind_RESOURCE_KIND_BOOK: changefeed.KindBook,
+	}
+	resourceKinds = map[changefeed.Kind]apiv1.ResourceKind{
+		changefeed.KindUser: apiv1.ResourceKind_RESOURCE_KIND_USER,
+		changefeed.KindBook: apiv1.ResourceKind_RESOURCE_KIND_BOOK,
+	}
+	changeTypes = map[changefeed.Type]apiv1.ChangeType{
+		changefeed.Created: apiv1.ChangeType_CHANGE_TYPE_CREATED,
+		changefeed.Updated: apiv1.ChangeType_CHANGE_TYPE_UPDATED,
+		changefeed.Deleted: apiv1.ChangeType_CHANGE_TYPE_DELETED,
+	}
+)
+
+// WatchServer serves apiv1.WatchService with the change feed of the
+// repositories.
+type WatchServer struct {
+	feed *changefeed.Feed
+}
+
+// Watch streams the changes after the resume token. The stream ends with OK
+// when the server shuts down, or shortly before the write timeout of the HTTP
+// server it is served by, if any, see changefeed.StreamContext.
+func (w *WatchServer) Watch(req *apiv1.WatchRequest, stream apiv1.WatchService_WatchServer) error {
+	var filter []changefeed.Kind
+	for _, kind := range req.GetKinds() {
+		k, ok := kinds[kind]
+		if !ok {
+			return errno.ErrParam
+		}
+		filter = append(filter, k)
+	}
+
+	sub, err := w.feed.Subscribe(req.GetResumeToken(), filter...)
+	if err != nil {
+		return watchError(err)
+	}
+	// tell the client that the subscription is established
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	ctx, cancel := changefeed.StreamContext(stream.Context())
+	defer cancel()
+	for {
+		event, err := sub.Next(ctx)
+		if err != nil {
+			// the server shuts down or the write timeout is near
+			if errors.Is(err, changefeed.ErrClosed) || stream.Context().Err() == nil && ctx.Err() != nil {
+				return nil
+			}
+			return watchError(err)
+		}
+		if err := stream.Send(toChangeEvent(event)); err != nil {
+			return err
+		}
+	}
+}
+
+func watchError(err error) error {
+	switch {
+	case errors.Is(err, changefeed.ErrInvalidToken):
+		return errno.ErrParam.WithError(err)
+	case errors.Is(err, changefeed.ErrTokenExpired):
+		return errno.ErrTokenExpired.WithError(err)
+	case errors.Is(err, changefeed.ErrClosed):
+		return errno.ErrUnavailable.WithError(err)
+	}
+	return err
+}
+
+func toChangeEvent(e changefeed.Event) *apiv1.ChangeEvent {
+	// the times are set by the feed, hence valid
+	ts, _ := ptypes.TimestampProto(e.Time)
+	event := &apiv1.ChangeEvent{
+		ResumeToken: e.Token,
+		Kind:        resourceKinds[e.Kind],
+		Type:        changeTypes[e.Type],
+		Id:          e.ID,
+		Time:        ts,
+	}
+
+	switch object := e.Object.(type) {
+	case *model.User:
+		event.Resource = &apiv1.ChangeEvent_User{User: &apiv1.User{Id: object.ID, Name: object.Name}}
+	case *model.Book:
+		event.Resource = &apiv1.ChangeEvent_Book{Book: &apiv1.Book{Id: object.ID, Name: object.Name}}
+	}
+	return event
+}
+
+// NewWatchServer returns a WatchServer instance.
+func NewWatchServer(feed *changefeed.Feed) *WatchServer {
+	return &WatchServer{
+		feed: feed,
+	}
+}
@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiv1 "go-template/api/proto/v1"
+	"go-template/internal/changefeed"
+	"go-template/internal/server/model"
+)
+
+func TestWatchServer_Watch(t *testing.T) {
+	feed := changefeed.New(4)
+	conn := dial(t, func(s *grpc.Server) {
+		apiv1.RegisterWatchServiceServer(s, NewWatchServer(feed))
+	})
+	client := apiv1.NewWatchServiceClient(conn)
+
+	feed.Publish(changefeed.KindUser, changefeed.Created, "1", &model.User{ID: "1", Name: "A"})
+	sub, err := feed.Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.Publish(changefeed.KindBook, changefeed.Updated, "2", &model.Book{ID: "2", Name: "B"})
+	feed.Publish(changefeed.KindUser, changefeed.Deleted, "1", nil)
+	book, _ := sub.Next(context.Background())
+
+	tests := []struct {
+		name     string
+		req      *apiv1.WatchRequest
+		want     []*apiv1.ChangeEvent
+		wantCode codes.Code
+	}{
+		{
+			name: "resume",
+			req:  &apiv1.WatchRequest{ResumeToken: book.Token},
+			want: []*apiv1.ChangeEvent{
+				{Kind: apiv1.ResourceKind_RESOURCE_KIND_USER, Type: apiv1.ChangeType_CHANGE_TYPE_DELETED, Id: "1"},
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "invalid token",
+			req:      &apiv1.WatchRequest{ResumeToken: "abc"},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "expired token",
+			req:      &apiv1.WatchRequest{ResumeToken: "abc-1"},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name: "unspecified kind",
+			req: &apiv1.WatchRequest{
+				Kinds: []apiv1.ResourceKind{apiv1.ResourceKind_RESOURCE_KIND_UNSPECIFIED},
+			},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			stream, err := client.Watch(ctx, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				got, err := stream.Recv()
+				if err != nil {
+					t.Fatalf("Watch() error = %v", err)
+				}
+				if got.Kind != want.Kind || got.Type != want.Type || got.Id != want.Id {
+					t.Errorf("Watch() = %v, want %v", got, want)
+				}
+			}
+			if tt.wantCode == codes.OK {
+				return
+			}
+			if _, err := stream.Recv(); status.Code(err) != tt.wantCode {
+				t.Errorf("Watch() error = %v, want code %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWatchServer_Live(t *testing.T) {
+	feed := changefeed.New(4)
+	conn := dial(t, func(s *grpc.Server) {
+		apiv1.RegisterWatchServiceServer(s, NewWatchServer(feed))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream, err := apiv1.NewWatchServiceClient(conn).Watch(ctx, &apiv1.WatchRequest{
+		Kinds: []apiv1.ResourceKind{apiv1.ResourceKind_RESOURCE_KIND_BOOK},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the subscription is established once the headers are received
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	feed.Publish(changefeed.KindUser, changefeed.Created, "1", &model.User{ID: "1", Name: "A"})
+	feed.Publish(changefeed.KindBook, changefeed.Created, "2", &model.Book{ID: "2", Name: "B"})
+	got, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetBook().GetName() != "B" || got.Type != apiv1.ChangeType_CHANGE_TYPE_CREATED || got.GetTime() == nil {
+		t.Errorf("Watch() = %v, want the creation of book 2", got)
+	}
+}
@@ -0,0 +1,212 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status of a check or of a report.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// ErrShuttingDown is reported by readiness while the server shuts down.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Checker checks whether a dependency works.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the result of a check.
+type Result struct {
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"-"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// MarshalJSON renders the duration in a readable form.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		Duration string `json:"duration"`
+	}{result(r), r.Duration.String()})
+}
+
+// Report is the result of all the checks of a kind.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK reports whether every check passed.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+
+	// mu is held while the check runs, so concurrent probes share a result
+	mu     sync.Mutex
+	result Result
+}
+
+func (c *check) run(ctx context.Context, ttl time.Duration) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < ttl {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	begin := time.Now()
+	err := c.checker.Check(ctx)
+	c.result = Result{
+		Status:    StatusOK,
+		Duration:  time.Since(begin),
+		CheckedAt: begin,
+	}
+	if err != nil {
+		c.result.Status = StatusUnavailable
+		c.result.Error = err.Error()
+	}
+	return c.result
+}
+
+// Registry holds the liveness and readiness checks of the server. Results
+// are cached for a while so that probes don't hammer the dependencies.
+type Registry struct {
+	ttl          time.Duration
+	shuttingDown int32
+
+	mu        sync.Mutex
+	liveness  []*check
+	readiness []*check
+}
+
+// NewRegistry returns a Registry caching results for ttl.
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{
+		ttl: ttl,
+	}
+}
+
+// RegisterLiveness registers a check that fails when the process must be
+// restarted. Liveness should not depend on external dependencies.
+func (r *Registry) RegisterLiveness(name string, c Checker, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, &check{name: name, checker: c, timeout: timeout})
+}
+
+// RegisterReadiness registers a check that fails when the server can not
+// serve traffic, e.g. because a dependency is down.
+func (r *Registry) RegisterReadiness(name string, c Checker, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, &check{name: name, checker: c, timeout: timeout})
+}
+
+// ShutDown marks the server as shutting down, readiness fails from now on.
+func (r *Registry) ShutDown() {
+	atomic.StoreInt32(&r.shuttingDown, 1)
+}
+
+// Liveness runs the liveness checks.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.mu.Lock()
+	checks := r.liveness
+	r.mu.Unlock()
+	return r.run(ctx, checks, false)
+}
+
+// Readiness runs the readiness checks.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.mu.Lock()
+	checks := r.readiness
+	r.mu.Unlock()
+	return r.run(ctx, checks, atomic.LoadInt32(&r.shuttingDown) == 1)
+}
+
+// run runs the checks concurrently.
+func (r *Registry) run(ctx context.Context, checks []*check, shuttingDown bool) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Result, len(checks)+1),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c *check) {
+			defer wg.Done()
+			result := c.run(ctx, r.ttl)
+			mu.Lock()
+			report.Checks[c.name] = result
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	if shuttingDown {
+		report.Checks["shutdown"] = Result{
+			Status:    StatusUnavailable,
+			Error:     ErrShuttingDown.Error(),
+			CheckedAt: time.Now(),
+		}
+	}
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+// LivezHandler serves the liveness report, with status 503 on failure.
+func (r *Registry) LivezHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// ReadyzHandler serves the readiness report, with status 503 on failure.
+func (r *Registry) ReadyzHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+func reportHandler(run func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := run(req.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if !report.OK() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
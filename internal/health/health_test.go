@@ -0,0 +1,175 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Readiness(t *testing.T) {
+	errDown := errors.New("connection refused")
+	ok := CheckerFunc(func(ctx context.Context) error { return nil })
+	down := CheckerFunc(func(ctx context.Context) error { return errDown })
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	tests := []struct {
+		name         string
+		checks       map[string]Checker
+		shuttingDown bool
+		wantStatus   string
+		wantChecks   map[string]string
+	}{
+		{
+			name:       "no checks",
+			checks:     map[string]Checker{},
+			wantStatus: StatusOK,
+			wantChecks: map[string]string{},
+		},
+		{
+			name:       "all checks pass",
+			checks:     map[string]Checker{"redis": ok, "database": ok},
+			wantStatus: StatusOK,
+			wantChecks: map[string]string{"redis": StatusOK, "database": StatusOK},
+		},
+		{
+			name:       "a check fails",
+			checks:     map[string]Checker{"redis": down, "database": ok},
+			wantStatus: StatusUnavailable,
+			wantChecks: map[string]string{"redis": StatusUnavailable, "database": StatusOK},
+		},
+		{
+			name:       "a check times out",
+			checks:     map[string]Checker{"redis": slow},
+			wantStatus: StatusUnavailable,
+			wantChecks: map[string]string{"redis": StatusUnavailable},
+		},
+		{
+			name:         "shutting down",
+			checks:       map[string]Checker{"redis": ok},
+			shuttingDown: true,
+			wantStatus:   StatusUnavailable,
+			wantChecks:   map[string]string{"redis": StatusOK, "shutdown": StatusUnavailable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(time.Minute)
+			for name, c := range tt.checks {
+				r.RegisterReadiness(name, c, 10*time.Millisecond)
+			}
+			if tt.shuttingDown {
+				r.ShutDown()
+			}
+
+			report := r.Readiness(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Errorf("Registry.Readiness() status = %v, want %v", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Errorf("Registry.Readiness() checks = %v, want %v", report.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if got := report.Checks[name].Status; got != want {
+					t.Errorf("Registry.Readiness() check %s = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	var calls int32
+	c := CheckerFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	r := NewRegistry(50 * time.Millisecond)
+	r.RegisterReadiness("redis", c, time.Second)
+
+	for i := 0; i < 3; i++ {
+		r.Readiness(context.Background())
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("checks within the ttl = %d, want 1", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	r.Readiness(context.Background())
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("checks after the ttl = %d, want 2", got)
+	}
+}
+
+func TestRegistry_Handlers(t *testing.T) {
+	r := NewRegistry(0)
+	r.RegisterLiveness("loop", CheckerFunc(func(ctx context.Context) error { return nil }), time.Second)
+	r.RegisterReadiness("database", CheckerFunc(func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}), time.Second)
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		wantCode   int
+		wantStatus string
+		wantCheck  string
+	}{
+		{
+			name:       "livez",
+			handler:    r.LivezHandler(),
+			wantCode:   http.StatusOK,
+			wantStatus: StatusOK,
+			wantCheck:  "loop",
+		},
+		{
+			name:       "readyz",
+			handler:    r.ReadyzHandler(),
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusUnavailable,
+			wantCheck:  "database",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+tt.name, nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", w.Code, tt.wantCode)
+			}
+			var body struct {
+				Status string `json:"status"`
+				Checks map[string]struct {
+					Status   string `json:"status"`
+					Error    string `json:"error"`
+					Duration string `json:"duration"`
+				} `json:"checks"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body.String(), err)
+			}
+			if body.Status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", body.Status, tt.wantStatus)
+			}
+			check, ok := body.Checks[tt.wantCheck]
+			if !ok {
+				t.Fatalf("checks = %v, want %s", body.Checks, tt.wantCheck)
+			}
+			if check.Duration == "" {
+				t.Errorf("check %s has no duration", tt.wantCheck)
+			}
+			if (check.Error != "") != (tt.wantStatus != StatusOK) {
+				t.Errorf("check %s error = %q", tt.wantCheck, check.Error)
+			}
+		})
+	}
+}
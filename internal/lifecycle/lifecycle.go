@@ -0,0 +1,198 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// Component is a part of the server with a managed lifecycle.
+type Component interface {
+	// Name identifies the component in logs and dependencies.
+	Name() string
+	// Start starts the component. Long running work must be done in the
+	// background, Start returns once the component is ready.
+	Start(ctx context.Context) error
+	// Stop stops the component, it must return when ctx is done.
+	Stop(ctx context.Context) error
+	// Health reports whether the component works.
+	Health(ctx context.Context) error
+}
+
+// Hook adapts functions to a Component, nil functions do nothing.
+type Hook struct {
+	ComponentName string
+	OnStart       func(ctx context.Context) error
+	OnStop        func(ctx context.Context) error
+	OnHealth      func(ctx context.Context) error
+}
+
+// Name returns the name of the component.
+func (h *Hook) Name() string {
+	return h.ComponentName
+}
+
+// Start calls OnStart.
+func (h *Hook) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+// Stop calls OnStop.
+func (h *Hook) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+// Health calls OnHealth.
+func (h *Hook) Health(ctx context.Context) error {
+	if h.OnHealth == nil {
+		return nil
+	}
+	return h.OnHealth(ctx)
+}
+
+type entry struct {
+	component Component
+	deps      []string
+}
+
+// Manager starts components in dependency order and stops them in reverse
+// order.
+type Manager struct {
+	mu      sync.Mutex
+	entries []entry
+	started []Component
+}
+
+// NewManager returns an empty Manager.
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Add adds a component that depends on the components named deps. Components
+// without dependencies between them start in the order they were added.
+func (m *Manager) Add(c Component, deps ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry{component: c, deps: deps})
+}
+
+// order sorts the components so that each one comes after its dependencies.
+func (m *Manager) order() ([]Component, error) {
+	byName := make(map[string]entry, len(m.entries))
+	for _, e := range m.entries {
+		if _, ok := byName[e.component.Name()]; ok {
+			return nil, fmt.Errorf("component %s is added twice", e.component.Name())
+		}
+		byName[e.component.Name()] = e
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	var (
+		state   = make(map[string]int, len(m.entries))
+		ordered = make([]Component, 0, len(m.entries))
+		visit   func(name string, path []string) error
+	)
+	visit = func(name string, path []string) error {
+		e, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("component %s depends on unknown component %s", path[len(path)-1], name)
+		}
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %v", append(path, name))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range e.deps {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, e.component)
+		return nil
+	}
+	for _, e := range m.entries {
+		if err := visit(e.component.Name(), nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Start starts the components in dependency order. If a component fails to
+// start, the components already started are stopped within ctx and the
+// errors are returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ordered, err := m.order()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range ordered {
+		begin := time.Now()
+		if err := c.Start(ctx); err != nil {
+			zap.L().Error("component failed to start", zap.String("component", c.Name()), zap.Error(err))
+			err = fmt.Errorf("start %s: %w", c.Name(), err)
+			return multierr.Append(err, m.stop(ctx))
+		}
+		zap.L().Info("component started", zap.String("component", c.Name()), zap.Duration("took", time.Since(begin)))
+		m.started = append(m.started, c)
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order. Every component is
+// stopped even if a previous one fails, all errors are returned.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stop(ctx)
+}
+
+func (m *Manager) stop(ctx context.Context) error {
+	var errs error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		c := m.started[i]
+		begin := time.Now()
+		if err := c.Stop(ctx); err != nil {
+			zap.L().Error("component failed to stop", zap.String("component", c.Name()), zap.Error(err))
+			errs = multierr.Append(errs, fmt.Errorf("stop %s: %w", c.Name(), err))
+			continue
+		}
+		zap.L().Info("component stopped", zap.String("component", c.Name()), zap.Duration("took", time.Since(begin)))
+	}
+	m.started = nil
+	return errs
+}
+
+// Health returns the health of the started components by name, a nil error
+// means healthy.
+func (m *Manager) Health(ctx context.Context) map[string]error {
+	m.mu.Lock()
+	started := append([]Component(nil), m.started...)
+	m.mu.Unlock()
+
+	health := make(map[string]error, len(started))
+	for _, c := range started {
+		health[c.Name()] = c.Health(ctx)
+	}
+	return health
+}
@@ -0,0 +1,151 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/multierr"
+)
+
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) component(name string, startErr, stopErr error) *Hook {
+	return &Hook{
+		ComponentName: name,
+		OnStart: func(ctx context.Context) error {
+			r.events = append(r.events, "start "+name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			r.events = append(r.events, "stop "+name)
+			return stopErr
+		},
+	}
+}
+
+func TestManager(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	type component struct {
+		name     string
+		deps     []string
+		startErr error
+		stopErr  error
+	}
+	tests := []struct {
+		name          string
+		components    []component
+		wantEvents    []string
+		wantStartErr  bool
+		wantStopErrs  int
+		skipStopPhase bool
+	}{
+		{
+			name: "dependency order",
+			components: []component{
+				{name: "http", deps: []string{"redis", "database"}},
+				{name: "metrics"},
+				{name: "redis"},
+				{name: "database"},
+			},
+			wantEvents: []string{
+				"start redis", "start database", "start http", "start metrics",
+				"stop metrics", "stop http", "stop database", "stop redis",
+			},
+		},
+		{
+			name: "start failure stops started components",
+			components: []component{
+				{name: "metrics"},
+				{name: "redis", startErr: errFailed},
+				{name: "http", deps: []string{"redis"}},
+			},
+			wantEvents:    []string{"start metrics", "start redis", "stop metrics"},
+			wantStartErr:  true,
+			skipStopPhase: true,
+		},
+		{
+			name: "every component is stopped",
+			components: []component{
+				{name: "redis", stopErr: errFailed},
+				{name: "database", stopErr: errFailed},
+				{name: "http"},
+			},
+			wantEvents: []string{
+				"start redis", "start database", "start http",
+				"stop http", "stop database", "stop redis",
+			},
+			wantStopErrs: 2,
+		},
+		{
+			name: "dependency cycle",
+			components: []component{
+				{name: "a", deps: []string{"b"}},
+				{name: "b", deps: []string{"a"}},
+			},
+			wantStartErr:  true,
+			skipStopPhase: true,
+		},
+		{
+			name: "unknown dependency",
+			components: []component{
+				{name: "a", deps: []string{"b"}},
+			},
+			wantStartErr:  true,
+			skipStopPhase: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			m := NewManager()
+			for _, c := range tt.components {
+				m.Add(r.component(c.name, c.startErr, c.stopErr), c.deps...)
+			}
+
+			err := m.Start(context.Background())
+			if (err != nil) != tt.wantStartErr {
+				t.Fatalf("Manager.Start() error = %v, wantErr %v", err, tt.wantStartErr)
+			}
+			if !tt.skipStopPhase {
+				err = m.Stop(context.Background())
+				if got := len(multierr.Errors(err)); got != tt.wantStopErrs {
+					t.Errorf("Manager.Stop() errors = %v, want %d errors", err, tt.wantStopErrs)
+				}
+			}
+			if !reflect.DeepEqual(r.events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", r.events, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestWorker(t *testing.T) {
+	started := make(chan struct{})
+	w := NewWorker("worker", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := w.Health(context.Background()); err == nil {
+		t.Errorf("Worker.Health() before start error = nil, want error")
+	}
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatalf("Worker.Start() error = %v", err)
+	}
+	<-started
+	if err := w.Health(context.Background()); err != nil {
+		t.Errorf("Worker.Health() error = %v, want nil", err)
+	}
+	if err := w.Stop(context.Background()); err != nil {
+		t.Errorf("Worker.Stop() error = %v", err)
+	}
+	if err := w.Health(context.Background()); err == nil {
+		t.Errorf("Worker.Health() after stop error = nil, want error")
+	}
+}
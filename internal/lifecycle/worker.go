@@ -0,0 +1,94 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Worker is a component that runs a function in the background until it is
+// stopped.
+type Worker struct {
+	name string
+	run  func(ctx context.Context) error
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// NewWorker returns a Worker running fn. fn must return when its context is
+// cancelled.
+func NewWorker(name string, fn func(ctx context.Context) error) *Worker {
+	return &Worker{
+		name: name,
+		run:  fn,
+	}
+}
+
+// Name returns the name of the worker.
+func (w *Worker) Name() string {
+	return w.name
+}
+
+// Start runs the function in the background.
+func (w *Worker) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	w.mu.Lock()
+	w.cancel, w.done, w.err = cancel, done, nil
+	w.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		err := w.run(runCtx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			zap.L().Error("worker stopped with error", zap.String("worker", w.name), zap.Error(err))
+		}
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+	}()
+	return nil
+}
+
+// Stop cancels the function and waits for it to return.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Health reports an error once the function has returned on its own.
+func (w *Worker) Health(ctx context.Context) error {
+	w.mu.Lock()
+	done, err := w.done, w.err
+	w.mu.Unlock()
+	if done == nil {
+		return errors.New("worker not started")
+	}
+	select {
+	case <-done:
+		if err == nil {
+			err = errors.New("worker exited")
+		}
+		return err
+	default:
+		return nil
+	}
+}
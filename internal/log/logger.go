@@ -9,22 +9,18 @@ import (
 
 type loggerKey struct{}
 
+type requestIDKey struct{}
+
+// requestIDField is the log field of the request ID, named after the HTTP
+// header.
+const requestIDField = "X-Request-ID"
+
+// level is shared by the loggers created by New so that it can be changed at
+// runtime with SetLevel.
+var level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
 func New(logLevel string, outputPaths, errorOutputPaths []string) (*zap.Logger, error) {
-	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	switch logLevel {
-	case "debug":
-		level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
-	case "info":
-		level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	case "warn":
-		level = zap.NewAtomicLevelAt(zapcore.WarnLevel)
-	case "error":
-		level = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
-	case "fatal":
-		level = zap.NewAtomicLevelAt(zapcore.FatalLevel)
-	case "panic":
-		level = zap.NewAtomicLevelAt(zapcore.PanicLevel)
-	}
+	level.SetLevel(parseLevel(logLevel))
 
 	zapEncoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "ts",
@@ -56,6 +52,29 @@ func New(logLevel string, outputPaths, errorOutputPaths []string) (*zap.Logger,
 	return zapConfig.Build()
 }
 
+// SetLevel changes the level of the loggers created by New.
+func SetLevel(logLevel string) {
+	level.SetLevel(parseLevel(logLevel))
+}
+
+func parseLevel(logLevel string) zapcore.Level {
+	switch logLevel {
+	case "debug":
+		return zapcore.DebugLevel
+	case "info":
+		return zapcore.InfoLevel
+	case "warn":
+		return zapcore.WarnLevel
+	case "error":
+		return zapcore.ErrorLevel
+	case "fatal":
+		return zapcore.FatalLevel
+	case "panic":
+		return zapcore.PanicLevel
+	}
+	return zapcore.InfoLevel
+}
+
 // Ctx return a *zap.Logger with context injected.
 func Ctx(ctx context.Context) *zap.Logger {
 	if ctx != nil {
@@ -70,3 +89,16 @@ func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
 	new_ctx := context.WithValue(ctx, loggerKey{}, logger)
 	return new_ctx
 }
+
+// WithRequestID returns a copy of ctx carrying the request ID and a logger
+// with the ID as field, see RequestID and Ctx.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return NewContext(ctx, zap.L().With(zap.String(requestIDField, requestID)))
+}
+
+// RequestID returns the request ID of ctx, or an empty string if it has none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
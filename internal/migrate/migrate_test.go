@@ -0,0 +1,110 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "single statement",
+			script: "DROP TABLE IF EXISTS user;\n",
+			want:   []string{"DROP TABLE IF EXISTS user;"},
+		},
+		{
+			name: "statements over several lines with comments",
+			script: `-- users
+CREATE TABLE user (
+    id VARCHAR(64) NOT NULL
+);
+
+INSERT INTO user (id) VALUES ('1');
+`,
+			want: []string{
+				"CREATE TABLE user (\n    id VARCHAR(64) NOT NULL\n);",
+				"INSERT INTO user (id) VALUES ('1');",
+			},
+		},
+		{
+			name:   "missing trailing semicolon",
+			script: "SELECT 1",
+			want:   []string{"SELECT 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		versions []int64
+		wantErr  bool
+	}{
+		{
+			name: "sorted by version",
+			files: map[string]string{
+				"0010_create_book.up.sql":   "CREATE TABLE book (id INT);",
+				"0002_create_user.up.sql":   "CREATE TABLE user (id INT);",
+				"0002_create_user.down.sql": "DROP TABLE user;",
+				"README.md":                 "ignored",
+			},
+			versions: []int64{2, 10},
+		},
+		{
+			name: "missing up file",
+			files: map[string]string{
+				"0001_create_user.down.sql": "DROP TABLE user;",
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated version",
+			files: map[string]string{
+				"0001_create_user.up.sql": "CREATE TABLE user (id INT);",
+				"0001_create_book.up.sql": "CREATE TABLE book (id INT);",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "migrations")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for name, content := range tt.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := Load(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var versions []int64
+			for _, m := range got {
+				versions = append(versions, m.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.versions) {
+				t.Errorf("Load() versions = %v, want %v", versions, tt.versions)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Seed executes the *.sql files in dir in lexical order and returns their
+// names. Seed files should be idempotent, e.g. use INSERT IGNORE, since they
+// are not recorded.
+func Seed(ctx context.Context, db *sqlx.DB, dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var done []string
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return done, err
+		}
+		if err := Exec(ctx, db, string(b)); err != nil {
+			return done, fmt.Errorf("seed %s failed: %w", filepath.Base(file), err)
+		}
+		done = append(done, filepath.Base(file))
+	}
+	return done, nil
+}
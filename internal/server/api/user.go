@@ -1,6 +1,8 @@
 package api
 
 import (
+	"fmt"
+	"go-template/internal/changefeed"
 	"go-template/internal/errno"
 	"go-template/internal/log"
 	"go-template/internal/server/cache"
@@ -9,7 +11,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gomodule/redigo/redis"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -23,8 +24,15 @@ func (u *UserAPI) Get(c *gin.Context) {
 	ctx := c.Request.Context()
 	logger := log.Ctx(ctx)
 	logger.Info("start getting users")
-	if _, err := u.service.Get(ctx, "1"); err != nil {
-		c.JSON(http.StatusInternalServerError, errno.ErrServer.WithError(err))
+	user, err := u.service.Get(ctx, "1")
+	if err != nil {
+		// rendered as errno.ErrServer by the Errors middleware
+		c.Error(err)
+		return
+	}
+	// a missing user is cached as an empty hash
+	if user.ID == "" {
+		c.Error(errno.ErrNotFound.WithError(fmt.Errorf("user %s not found", "1")))
 		return
 	}
 
@@ -35,8 +43,8 @@ func (u *UserAPI) Get(c *gin.Context) {
 }
 
 // NewUserAPI return an userAPI instance
-func NewUserAPI(pool *redis.Pool, db *sqlx.DB) *UserAPI {
-	repo := repository.NewUserRepo(db)
+func NewUserAPI(pool cache.Pool, db *sqlx.DB, feed *changefeed.Feed) *UserAPI {
+	repo := repository.NewUserRepo(db, feed)
 	cache := cache.NewUserCache(pool)
 	service := service.NewUserService(repo, cache)
 	return &UserAPI{
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-template/internal/server/middleware"
+	"go-template/internal/server/model"
+	"go-template/internal/server/service"
+)
+
+type mockUserService struct {
+	service.UserService
+	user *model.User
+	err  error
+}
+
+func (s *mockUserService) Get(ctx context.Context, userID string) (*model.User, error) {
+	return s.user, s.err
+}
+
+func TestUserAPI_Get(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		service    *mockUserService
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{
+			name:       "found",
+			service:    &mockUserService{user: &model.User{ID: "1", Name: "A"}},
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"code": float64(0), "msg": "ok"},
+		},
+		{
+			name:       "not found",
+			service:    &mockUserService{user: &model.User{}},
+			wantStatus: http.StatusNotFound,
+			wantBody:   map[string]interface{}{"code": float64(10003), "message": "资源不存在"},
+		},
+		{
+			name:       "service failure",
+			service:    &mockUserService{err: errors.New("dial tcp: connection refused")},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   map[string]interface{}{"code": float64(10001), "message": "服务异常，请联系管理员"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.Errors(middleware.ErrorsConfig{}))
+			r.GET("/users", (&UserAPI{service: tt.service}).Get)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body, err)
+			}
+			if !reflect.DeepEqual(body, tt.wantBody) {
+				t.Errorf("body = %v, want %v", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"go-template/internal/changefeed"
+	"go-template/internal/errno"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval is how often a comment is sent on an idle event stream,
+// so that proxies do not close it.
+const heartbeatInterval = 15 * time.Second
+
+// WatchAPI is the controller streaming the changes of the users and books.
+type WatchAPI struct {
+	feed *changefeed.Feed
+}
+
+// Watch is the handler streaming the changes as server-sent events, whose id
+// is the resume token. Browsers resume with the Last-Event-ID header, other
+// clients may pass the resume_token query parameter. The kind query
+// parameter, user or book, filters the events.
+//
+// The stream ends when the server shuts down or shortly before its write
+// timeout, the client then reconnects. A token that is too old is answered
+// with 410 Gone, the client must read the resources again.
+func (w *WatchAPI) Watch(c *gin.Context) {
+	token := c.GetHeader("Last-Event-ID")
+	if token == "" {
+		token = c.Query("resume_token")
+	}
+	var kinds []changefeed.Kind
+	for _, kind := range c.QueryArray("kind") {
+		switch k := changefeed.Kind(kind); k {
+		case changefeed.KindUser, changefeed.KindBook:
+			kinds = append(kinds, k)
+		default:
+			c.Error(errno.ErrParam)
+			return
+		}
+	}
+
+	sub, err := w.feed.Subscribe(token, kinds...)
+	switch {
+	case errors.Is(err, changefeed.ErrInvalidToken):
+		c.Error(errno.ErrParam.WithError(err))
+		return
+	case errors.Is(err, changefeed.ErrTokenExpired):
+		c.Error(errno.ErrTokenExpired.WithError(err))
+		return
+	case err != nil:
+		c.Error(errno.ErrUnavailable.WithError(err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	// disable the buffering of nginx
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Content-Type", "text/event-stream")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx, cancel := changefeed.StreamContext(c.Request.Context())
+	defer cancel()
+	for {
+		next, cancelNext := context.WithTimeout(ctx, heartbeatInterval)
+		event, err := sub.Next(next)
+		cancelNext()
+		switch {
+		case err == nil:
+			c.Render(-1, sse.Event{Id: event.Token, Data: event})
+		case errors.Is(err, changefeed.ErrClosed) || ctx.Err() != nil:
+			// the server shuts down, the client is gone or the write
+			// timeout is near
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			c.Writer.WriteString(": heartbeat\n\n")
+		default:
+			// fell behind the feed
+			expired := errno.Localize(errno.ErrTokenExpired, c.GetHeader("Accept-Language"))
+			c.Render(-1, sse.Event{Event: "error", Data: gin.H{
+				"code":    errno.Code(expired),
+				"message": errno.Message(expired),
+			}})
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// NewWatchAPI returns a WatchAPI instance.
+func NewWatchAPI(feed *changefeed.Feed) *WatchAPI {
+	return &WatchAPI{
+		feed: feed,
+	}
+}
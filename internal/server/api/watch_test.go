@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-template/internal/changefeed"
+	"go-template/internal/server/middleware"
+	"go-template/internal/server/model"
+)
+
+func TestWatchAPI_Watch(t *testing.T) {
+	feed := changefeed.New(4)
+	feed.Publish(changefeed.KindUser, changefeed.Created, "1", &model.User{ID: "1", Name: "A"})
+	sub, err := feed.Subscribe("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed.Publish(changefeed.KindBook, changefeed.Created, "2", &model.Book{ID: "2", Name: "B"})
+	feed.Publish(changefeed.KindUser, changefeed.Deleted, "1", nil)
+	book, _ := sub.Next(context.Background())
+	deleted, _ := sub.Next(context.Background())
+
+	r := gin.New()
+	r.Use(middleware.Errors(middleware.ErrorsConfig{}))
+	r.GET("/events", NewWatchAPI(feed).Watch)
+	srv := httptest.NewUnstartedServer(r)
+	// end the streams quickly, as if the write timeout was short
+	srv.Config.ConnContext = func(ctx context.Context, _ net.Conn) context.Context {
+		return changefeed.WithWriteTimeout(ctx, 100*time.Millisecond)
+	}
+	srv.Start()
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		header     string
+		query      string
+		wantStatus int
+		wantLines  []string
+	}{
+		{
+			name:       "resume with Last-Event-ID",
+			header:     book.Token,
+			wantStatus: http.StatusOK,
+			wantLines: []string{
+				"id:" + deleted.Token,
+				`data:{"token":"` + deleted.Token + `","kind":"user","type":"deleted","id":"1","time":`,
+			},
+		},
+		{
+			name:       "resume with a filter",
+			query:      "?kind=book&resume_token=" + book.Token,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "expired token",
+			header:     "abc-1",
+			wantStatus: http.StatusGone,
+		},
+		{
+			name:       "unknown kind",
+			query:      "?kind=author",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("GET /events status = %v, want %v", res.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Errorf("GET /events Content-Type = %v, want text/event-stream", ct)
+			}
+
+			// the stream ends before the write timeout
+			var lines []string
+			scanner := bufio.NewScanner(res.Body)
+			for scanner.Scan() {
+				if line := scanner.Text(); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("read stream error = %v", err)
+			}
+			if len(lines) != len(tt.wantLines) {
+				t.Fatalf("GET /events = %q, want %q", lines, tt.wantLines)
+			}
+			for i, want := range tt.wantLines {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("GET /events line %d = %q, want prefix %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -1,9 +1,20 @@
 package cache
 
-import "go-template/internal/server/model"
+import (
+	"go-template/internal/server/model"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Pool is the subset of *redis.Pool used by caches. It allows the pool to be
+// replaced at runtime without rebuilding the caches.
+type Pool interface {
+	Get() redis.Conn
+}
 
 // UserCache is an interface to get user info from cache.
 type UserCache interface {
 	Get(userID string) (*model.UserCache, error)
 	Set(userID string, user *model.UserCache) error
+	Delete(userID string) error
 }
@@ -8,11 +8,11 @@ import (
 )
 
 type userCache struct {
-	pool *redis.Pool
+	pool Pool
 }
 
 // NewUserCache creates an UserCache instance.
-func NewUserCache(pool *redis.Pool) UserCache {
+func NewUserCache(pool Pool) UserCache {
 	return &userCache{
 		pool: pool,
 	}
@@ -45,3 +45,13 @@ func (c *userCache) Set(userID string, user *model.UserCache) error {
 	}
 	return nil
 }
+
+func (c *userCache) Delete(userID string) error {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("DEL", userID); err != nil {
+		return err
+	}
+	return nil
+}
@@ -137,3 +137,33 @@ func Test_userCache_Set(t *testing.T) {
 		})
 	}
 }
+
+func Test_userCache_Delete(t *testing.T) {
+
+	// miniredis for unittest
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	s.HSet("1", "id", "1", "name", "A")
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", s.Addr())
+		},
+	}
+
+	c := &userCache{pool: pool}
+	if err := c.Delete("1"); err != nil {
+		t.Fatalf("userCache.Delete() error = %v", err)
+	}
+	if s.Exists("1") {
+		t.Error("userCache.Delete() kept the user")
+	}
+	// a missing user reads as an empty one
+	got, err := c.Get("1")
+	if err != nil || !reflect.DeepEqual(got, &model.UserCache{}) {
+		t.Errorf("userCache.Get() after Delete = %v, %v, want an empty user", got, err)
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+var errListenerClosed = errors.New("listener closed")
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+// sharedListener accepts connections on a single port and hands them to
+// the views created from it. It lets a http.Server be replaced, e.g. to apply
+// new timeouts, without closing the port: the new server starts serving on a
+// fresh view while the old one is shut down.
+type sharedListener struct {
+	net.Listener
+	conns     chan acceptResult
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newSharedListener(l net.Listener) *sharedListener {
+	sl := &sharedListener{
+		Listener: l,
+		conns:    make(chan acceptResult),
+		closed:   make(chan struct{}),
+	}
+	go sl.acceptLoop()
+	return sl
+}
+
+func (l *sharedListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		select {
+		case l.conns <- acceptResult{conn: conn, err: err}:
+		case <-l.closed:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+	}
+}
+
+// view returns a listener that receives connections until it is closed.
+// Closing a view does not close the shared listener.
+func (l *sharedListener) view() net.Listener {
+	return &listenerView{
+		shared: l,
+		done:   make(chan struct{}),
+	}
+}
+
+// Close closes the underlying listener.
+func (l *sharedListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
+
+func (l *sharedListener) requeue(r acceptResult) {
+	select {
+	case l.conns <- r:
+	case <-l.closed:
+		if r.conn != nil {
+			r.conn.Close()
+		}
+	}
+}
+
+type listenerView struct {
+	shared    *sharedListener
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (v *listenerView) Accept() (net.Conn, error) {
+	select {
+	case r := <-v.shared.conns:
+		select {
+		case <-v.done:
+			// the view was closed while accepting, hand the connection back
+			go v.shared.requeue(r)
+			return nil, errListenerClosed
+		default:
+			return r.conn, r.err
+		}
+	case <-v.done:
+		return nil, errListenerClosed
+	case <-v.shared.closed:
+		return nil, errListenerClosed
+	}
+}
+
+func (v *listenerView) Close() error {
+	v.closeOnce.Do(func() { close(v.done) })
+	return nil
+}
+
+func (v *listenerView) Addr() net.Addr {
+	return v.shared.Addr()
+}
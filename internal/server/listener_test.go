@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSharedListener_View(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := newSharedListener(l)
+	defer shared.Close()
+
+	// a view accepts the connections of the shared listener
+	first := shared.view()
+	accept(t, first, l.Addr())
+
+	// closing a view ends its pending Accept and keeps the port open for the
+	// next view
+	pending := make(chan error, 1)
+	go func() {
+		_, err := first.Accept()
+		pending <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	first.Close()
+	select {
+	case err := <-pending:
+		if err != errListenerClosed {
+			t.Errorf("Accept() on a closed view error = %v, want %v", err, errListenerClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Accept() on a closed view is still blocked")
+	}
+	second := shared.view()
+	accept(t, second, l.Addr())
+
+	// closing the shared listener ends the Accept of every view
+	shared.Close()
+	if _, err := second.Accept(); err != errListenerClosed {
+		t.Errorf("Accept() after closing the shared listener error = %v, want %v", err, errListenerClosed)
+	}
+}
+
+// accept dials addr and checks that l accepts the connection.
+func accept(t *testing.T, l net.Listener, addr net.Addr) {
+	t.Helper()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Errorf("Accept() error = %v", err)
+		}
+		accepted <- conn
+	}()
+	client, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	select {
+	case conn := <-accepted:
+		if conn != nil {
+			conn.Close()
+		}
+	case <-time.After(time.Second):
+		t.Fatal("connection not accepted")
+	}
+}
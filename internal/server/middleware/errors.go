@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-template/internal/errno"
+	"go-template/internal/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// errorResponse is the body of the error responses, unless problem
+// documents are rendered.
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// Detail is the cause of the error, only rendered in debug mode as it
+	// may leak internals such as database errors.
+	Detail string `json:"detail,omitempty"`
+}
+
+// problemResponse is a RFC 7807 problem document. Code is an extension
+// member with the errno code.
+type problemResponse struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     int    `json:"code"`
+}
+
+// problemContentType is the media type of the problem documents.
+const problemContentType = "application/problem+json"
+
+// ErrorsConfig configures the Errors middleware.
+type ErrorsConfig struct {
+	// Debug renders the detail of the errors.
+	Debug bool
+	// Problem renders problem documents to every client, not only to the
+	// ones accepting them.
+	Problem bool
+	// ProblemTypeBase is followed by the errno code in the type of the
+	// problem documents, e.g. "https://docs.example.com/errors/".
+	ProblemTypeBase string
+}
+
+type errorsKey struct{}
+
+// Errors is a middleware that renders the last error added to the context
+// with c.Error, unless the handler already wrote a response. The status is
+// the one of the errno code, e.g. 400 for errno.ErrParam, errors other than
+// errno ones are rendered as errno.ErrServer. The message is in the language
+// of the Accept-Language header, see errno.Localize. The detail of the errors
+// is rendered in debug mode only. The errors are logged by the Logger
+// middleware.
+//
+// The body is a {code,message} object, or a RFC 7807 problem document if
+// config.Problem is set or the client accepts application/problem+json. The
+// instance of the problems is the request ID.
+func Errors(config ErrorsConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// let plain http.Handlers report their errors too, see ReportError
+		ctx := context.WithValue(c.Request.Context(), errorsKey{}, c)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		err := errno.Localize(errno.FromError(last.Err), c.GetHeader("Accept-Language"))
+		message := errno.Message(err)
+		status := errno.HTTPStatus(err)
+		var detail string
+		if config.Debug {
+			detail = errno.Detail(err)
+		}
+
+		if !config.Problem && !acceptsProblem(c.Request) {
+			c.JSON(status, errorResponse{
+				Code:    errno.Code(err),
+				Message: message,
+				Detail:  detail,
+			})
+			return
+		}
+		// render.JSON keeps the content type already set
+		c.Header("Content-Type", problemContentType)
+		c.Render(status, render.JSON{Data: problemResponse{
+			Type:     config.ProblemTypeBase + strconv.Itoa(errno.Code(err)),
+			Title:    message,
+			Status:   status,
+			Detail:   detail,
+			Instance: log.RequestID(c.Request.Context()),
+			Code:     errno.Code(err),
+		}})
+	}
+}
+
+// acceptsProblem reports whether the Accept header of r explicitly lists
+// problem documents, wildcards do not count.
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil || mediaType != problemContentType {
+				continue
+			}
+			if q, ok := params["q"]; ok {
+				if weight, err := strconv.ParseFloat(q, 64); err != nil || weight == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ReportError adds err to the gin context of r, to be rendered by the Errors
+// middleware. It returns false if r is not served behind the middleware.
+func ReportError(r *http.Request, err error) bool {
+	c, ok := r.Context().Value(errorsKey{}).(*gin.Context)
+	if !ok {
+		return false
+	}
+	c.Error(err)
+	return true
+}
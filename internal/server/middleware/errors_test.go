@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-template/internal/errno"
+	"go-template/internal/log"
+)
+
+func TestErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		debug          bool
+		acceptLanguage string
+		handler        gin.HandlerFunc
+		wantStatus     int
+		wantBody       map[string]interface{}
+	}{
+		{
+			name:       "param error",
+			handler:    func(c *gin.Context) { c.Error(errno.ErrParam.WithError(errors.New("id is empty"))) },
+			wantStatus: http.StatusBadRequest,
+			wantBody:   map[string]interface{}{"code": float64(10002), "message": "参数有误"},
+		},
+		{
+			name:       "detail in debug mode",
+			debug:      true,
+			handler:    func(c *gin.Context) { c.Error(errno.ErrNotFound.WithError(errors.New("sql: no rows"))) },
+			wantStatus: http.StatusNotFound,
+			wantBody:   map[string]interface{}{"code": float64(10003), "message": "资源不存在", "detail": "sql: no rows"},
+		},
+		{
+			name:           "accepted language",
+			acceptLanguage: "en-US,en;q=0.9",
+			handler:        func(c *gin.Context) { c.Error(errno.ErrNotFound) },
+			wantStatus:     http.StatusNotFound,
+			wantBody:       map[string]interface{}{"code": float64(10003), "message": "Resource not found"},
+		},
+		{
+			name:       "not an errno error",
+			handler:    func(c *gin.Context) { c.Error(errors.New("dial tcp: connection refused")) },
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   map[string]interface{}{"code": float64(10001), "message": "服务异常，请联系管理员"},
+		},
+		{
+			name: "the last error wins",
+			handler: func(c *gin.Context) {
+				c.Error(errno.ErrParam)
+				c.Error(errno.ErrUnavailable)
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   map[string]interface{}{"code": float64(10005), "message": "服务暂不可用，请稍后重试"},
+		},
+		{
+			name: "response already written",
+			handler: func(c *gin.Context) {
+				c.JSON(http.StatusAccepted, map[string]interface{}{"code": 0})
+				c.Error(errno.ErrServer)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   map[string]interface{}{"code": float64(0)},
+		},
+		{
+			name: "reported by a http.Handler",
+			handler: gin.WrapF(func(w http.ResponseWriter, r *http.Request) {
+				ReportError(r, errno.ErrParam)
+			}),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   map[string]interface{}{"code": float64(10002), "message": "参数有误"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Errors(ErrorsConfig{Debug: tt.debug}))
+			r.GET("/", tt.handler)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body, err)
+			}
+			if !reflect.DeepEqual(body, tt.wantBody) {
+				t.Errorf("body = %v, want %v", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestErrors_Problem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	notFound := map[string]interface{}{
+		"type":     "https://docs.example.com/errors/10003",
+		"title":    "资源不存在",
+		"status":   float64(http.StatusNotFound),
+		"instance": "abc",
+		"code":     float64(10003),
+	}
+	tests := []struct {
+		name            string
+		config          ErrorsConfig
+		accept          string
+		wantContentType string
+		wantBody        map[string]interface{}
+	}{
+		{
+			name:            "envelope by default",
+			accept:          "application/json, */*",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        map[string]interface{}{"code": float64(10003), "message": "资源不存在"},
+		},
+		{
+			name:            "accepted by the client",
+			accept:          "application/json;q=0.9, application/problem+json",
+			wantContentType: problemContentType,
+			wantBody:        notFound,
+		},
+		{
+			name:            "refused by the client",
+			accept:          "application/problem+json;q=0",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        map[string]interface{}{"code": float64(10003), "message": "资源不存在"},
+		},
+		{
+			name:            "configured",
+			config:          ErrorsConfig{Problem: true},
+			wantContentType: problemContentType,
+			wantBody:        notFound,
+		},
+		{
+			name:            "detail in debug mode",
+			config:          ErrorsConfig{Problem: true, Debug: true},
+			wantContentType: problemContentType,
+			wantBody: map[string]interface{}{
+				"type":     "https://docs.example.com/errors/10003",
+				"title":    "资源不存在",
+				"status":   float64(http.StatusNotFound),
+				"detail":   "sql: no rows",
+				"instance": "abc",
+				"code":     float64(10003),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ProblemTypeBase = "https://docs.example.com/errors/"
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(log.WithRequestID(c.Request.Context(), "abc"))
+			}, Errors(tt.config))
+			r.GET("/", func(c *gin.Context) { c.Error(errno.ErrNotFound.WithError(errors.New("sql: no rows"))) })
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body, err)
+			}
+			if !reflect.DeepEqual(body, tt.wantBody) {
+				t.Errorf("body = %v, want %v", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"go-template/internal/certs"
+	"go-template/internal/log"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ClientIdentity is a middleware that exposes the identity of clients
+// authenticated with a TLS certificate. Handlers get it with
+// certs.FromContext(c.Request.Context()), it is also added to the logger.
+func ClientIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := certs.PeerIdentity(c.Request.TLS)
+		if !ok {
+			c.Next()
+			return
+		}
+		logger := log.Ctx(c.Request.Context()).With(zap.String("client", id.CommonName))
+		ctx := log.NewContext(certs.NewContext(c.Request.Context(), id), logger)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -3,9 +3,12 @@ package middleware
 import (
 	"bytes"
 	"fmt"
+	"go-template/internal/errno"
 	"go-template/internal/log"
 	"io"
 	"io/ioutil"
+	"mime"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,11 +22,33 @@ type bodyLogWriter struct {
 }
 
 func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if !w.streaming() {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
-// Logger is a gin common logging middleware.
+func (w bodyLogWriter) WriteString(s string) (int, error) {
+	if !w.streaming() {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// streaming reports whether the response is an event stream, whose body is
+// not kept as it lasts as long as the client stays connected.
+func (w bodyLogWriter) streaming() bool {
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	return mediaType == eventStreamContentType
+}
+
+// eventStreamContentType is the media type of server-sent events.
+const eventStreamContentType = "text/event-stream"
+
+// Logger is a gin common logging middleware. The response of a request
+// failing with an error added with c.Error is logged with the error, at
+// error level with the stack recorded by errno.WithError if any for server
+// errors and at info level for the others.
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := log.Ctx(c.Request.Context())
@@ -60,9 +85,25 @@ func Logger() gin.HandlerFunc {
 			zap.Duration("duration", time.Since(start)),
 		}
 
-		logger.Debug(
-			fmt.Sprintf("response: %s", ww.body.String()),
-			respFields...,
-		)
+		body = ww.body.Bytes()
+		if ww.streaming() {
+			body = []byte("<event stream>")
+		}
+		message := fmt.Sprintf("response: %s", body)
+
+		last := c.Errors.Last()
+		switch {
+		case last == nil:
+			logger.Debug(message, respFields...)
+		case ww.Status() >= http.StatusInternalServerError:
+			respFields = append(respFields, zap.Error(last.Err))
+			if stack := errno.Stack(last.Err); stack != "" {
+				respFields = append(respFields, zap.String("errorStack", stack))
+			}
+			logger.Error(message, respFields...)
+		default:
+			respFields = append(respFields, zap.Error(last.Err))
+			logger.Info(message, respFields...)
+		}
 	}
 }
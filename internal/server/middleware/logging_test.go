@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"go-template/internal/errno"
+	"go-template/internal/log"
+)
+
+func TestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zap.DebugLevel)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(log.NewContext(c.Request.Context(), zap.New(core)))
+	}, Logger())
+	r.GET("/json", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": "1"}) })
+	r.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			c.SSEvent("change", gin.H{"id": i})
+			c.Writer.Flush()
+		}
+	})
+
+	tests := []struct {
+		path     string
+		wantBody string
+	}{
+		{path: "/json", wantBody: `response: {"id":"1"}`},
+		{path: "/events", wantBody: "response: <event stream>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			entries := logs.TakeAll()
+			if len(entries) != 2 {
+				t.Fatalf("logged %d entries, want 2", len(entries))
+			}
+			if got := entries[1].Message; got != tt.wantBody {
+				t.Errorf("response logged as %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestBodyLogWriter_EventStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	w := bodyLogWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	for i := 0; i < 100; i++ {
+		w.WriteString(":heartbeat\n\n")
+	}
+	if w.body.Len() != 0 {
+		t.Errorf("kept %d bytes of the event stream, want none", w.body.Len())
+	}
+}
+
+func TestLogger_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errno.CaptureStacks(true)
+	defer errno.CaptureStacks(false)
+
+	core, logs := observer.New(zap.InfoLevel)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(log.NewContext(c.Request.Context(), zap.New(core)))
+	}, Logger(), Errors(ErrorsConfig{}))
+	r.GET("/server", func(c *gin.Context) { c.Error(errno.ErrServer.WithError(errors.New("connection refused"))) })
+	r.GET("/client", func(c *gin.Context) { c.Error(errno.ErrParam.WithError(errors.New("id is empty"))) })
+
+	for _, path := range []string{"/server", "/client"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// the response line is the only entry of each request
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries, want 2", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	stack, ok := fields["errorStack"].(string)
+	if entries[0].Level != zap.ErrorLevel || fields["status"] != int64(http.StatusInternalServerError) ||
+		!ok || !strings.Contains(stack, "logging_test.go:") {
+		t.Errorf("server error logged %v %v, want its status and stack at error level", entries[0].Level, fields)
+	}
+	fields = entries[1].ContextMap()
+	if _, ok := fields["errorStack"]; ok || entries[1].Level != zap.InfoLevel || fields["error"] == nil {
+		t.Errorf("client error logged %v %v, want the error without stack at info level", entries[1].Level, fields)
+	}
+}
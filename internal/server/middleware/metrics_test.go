@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"go-template/internal/errno"
+)
+
+func TestPrometheus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reg := prometheus.NewRegistry()
+	p := NewPrometheusMiddleware(reg)
+	r := gin.New()
+	// in the order of the router, recovering panics after measuring
+	r.Use(p.Handler(), gin.RecoveryWithWriter(ioutil.Discard), Errors(ErrorsConfig{}))
+	r.GET("/users/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.Error(errno.ErrNotFound)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/users", func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": "1"}) })
+	r.GET("/ok", func(c *gin.Context) {})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodGet, "/users/0"},
+		{http.MethodPost, "/users"},
+		{http.MethodGet, "/ok"},
+		{http.MethodGet, "/panic"},
+		{http.MethodGet, "/missing/1"},
+		{http.MethodGet, "/missing/2"},
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	tests := []struct {
+		method, route, status string
+		want                  float64
+	}{
+		{"GET", "/users/:id", "204", 2},
+		{"GET", "/users/:id", "404", 1},
+		{"POST", "/users", "201", 1},
+		{"GET", "/ok", "200", 1},
+		{"GET", "/panic", "500", 1},
+		{"GET", unmatchedRoute, "404", 2},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(p.Counter.WithLabelValues(tt.method, tt.route, tt.status)); got != tt.want {
+			t.Errorf("http_requests_total{method=%q,route=%q,status=%q} = %v, want %v", tt.method, tt.route, tt.status, got, tt.want)
+		}
+	}
+	if got := testutil.CollectAndCount(p.Counter); got != len(tests) {
+		t.Errorf("http_requests_total has %d series, want %d", got, len(tests))
+	}
+	if got := testutil.CollectAndCount(p.Histogram); got != len(tests) {
+		t.Errorf("http_request_duration_seconds has %d series, want %d", got, len(tests))
+	}
+
+	if reused := NewPrometheusMiddleware(reg); reused.Counter != p.Counter {
+		t.Error("NewPrometheusMiddleware() did not reuse the registered metrics")
+	}
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"go.uber.org/zap"
 )
 
 const (
@@ -20,8 +19,7 @@ func RequestID() gin.HandlerFunc {
 		if requestID == "" {
 			requestID = uuid.New().String()
 		}
-		logger := zap.L().With(zap.String(requestIDHeader, requestID))
-		ctx := log.NewContext(c.Request.Context(), logger)
+		ctx := log.WithRequestID(c.Request.Context(), requestID)
 		c.Request = c.Request.WithContext(ctx)
 		c.Header(requestIDHeader, requestID)
 		c.Next()
@@ -1,6 +1,6 @@
 package model
 
 type Book struct {
-	ID   string `db:"id"`
-	Name string `db:"name"`
+	ID   string `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
 }
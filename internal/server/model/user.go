@@ -1,8 +1,8 @@
 package model
 
 type User struct {
-	ID   string `db:"id"`
-	Name string `db:"name"`
+	ID   string `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
 }
 
 type UserCache struct {
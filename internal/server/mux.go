@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// GRPCServer is a gRPC server served on the HTTP port.
+type GRPCServer interface {
+	http.Handler
+	// Stop closes the connections and cancels the pending RPCs.
+	Stop()
+}
+
+// drainPollInterval is how often protocolMux.drain checks for requests
+// still being served, as http.Server.Shutdown does.
+const drainPollInterval = 10 * time.Millisecond
+
+// protocolMux routes HTTP/2 requests with an application/grpc content type
+// to the gRPC server and the other requests, HTTP/1.1 or HTTP/2, to the HTTP
+// handler.
+//
+// Cleartext HTTP/2 connections (h2c) are hijacked from the http.Server, which
+// then neither tracks them nor waits for them on shutdown. protocolMux counts
+// the requests in flight so that the shutdown can wait for them too.
+type protocolMux struct {
+	grpc   GRPCServer
+	http   http.Handler
+	active int64
+}
+
+func (m *protocolMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&m.active, 1)
+	defer atomic.AddInt64(&m.active, -1)
+
+	if m.grpc != nil && isGRPC(r) {
+		m.grpc.ServeHTTP(w, r)
+		return
+	}
+	m.http.ServeHTTP(w, r)
+}
+
+func isGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// drain waits until no request is in flight or ctx is done.
+func (m *protocolMux) drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&m.active) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// withH2C lets srv serve HTTP/2 over cleartext connections, in addition to
+// HTTP/2 negotiated by TLS. Shutting down srv sends a GOAWAY frame to the h2c
+// connections so that clients stop opening streams on them.
+func withH2C(srv *http.Server) error {
+	h2s := &http2.Server{}
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return err
+	}
+	srv.Handler = h2c.NewHandler(srv.Handler, h2s)
+	return nil
+}
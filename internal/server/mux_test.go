@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"go-template/internal/config"
+	igrpc "go-template/internal/grpc"
+)
+
+func TestProtocolMux(t *testing.T) {
+	g, err := igrpc.NewServer(config.GRPC{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetServing(true)
+	mux := &protocolMux{
+		grpc: g,
+		http: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		}),
+	}
+	srv := &http.Server{Handler: mux}
+	if err := withH2C(srv); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(listener)
+	defer srv.Close()
+	addr := listener.Addr().String()
+
+	// HTTP/1.1 goes to the HTTP handler
+	resp, err := http.Get("http://" + addr)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "HTTP/1.1" {
+		t.Errorf("GET body = %q, want HTTP/1.1", body)
+	}
+
+	// h2c gRPC goes to the gRPC server
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Dial error = %v", err)
+	}
+	defer conn.Close()
+	res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Health.Check error = %v", err)
+	}
+	if res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("Health.Check status = %v, want SERVING", res.Status)
+	}
+
+	// every request is done
+	if err := mux.drain(ctx); err != nil {
+		t.Errorf("protocolMux.drain() error = %v", err)
+	}
+}
+
+func TestProtocolMux_Drain(t *testing.T) {
+	release := make(chan struct{})
+	mux := &protocolMux{http: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		mux.ServeHTTP(nil, req)
+	}()
+	for {
+		time.Sleep(drainPollInterval)
+		if mux.drain(canceledContext()) != nil {
+			break
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	close(release)
+	if err := mux.drain(ctx); err != nil {
+		t.Errorf("protocolMux.drain() error = %v", err)
+	}
+	<-done
+}
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
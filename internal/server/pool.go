@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// cachePool is a redis pool whose underlying *redis.Pool can be replaced at
+// runtime, e.g. when the pool size is changed in the configuration file.
+type cachePool struct {
+	v atomic.Value // *redis.Pool
+}
+
+func newCachePool(pool *redis.Pool) *cachePool {
+	p := &cachePool{}
+	p.v.Store(pool)
+	return p
+}
+
+func (p *cachePool) load() *redis.Pool {
+	return p.v.Load().(*redis.Pool)
+}
+
+// Get gets a connection from the current pool.
+func (p *cachePool) Get() redis.Conn {
+	return p.load().Get()
+}
+
+// GetContext gets a connection from the current pool, waiting and dialing
+// until ctx is done at most.
+func (p *cachePool) GetContext(ctx context.Context) (redis.Conn, error) {
+	return p.load().GetContext(ctx)
+}
+
+// swap replaces the current pool and closes the previous one. Connections
+// borrowed from the previous pool are closed when they are returned.
+func (p *cachePool) swap(pool *redis.Pool) error {
+	old := p.load()
+	p.v.Store(pool)
+	return old.Close()
+}
+
+// Close closes the current pool.
+func (p *cachePool) Close() error {
+	return p.load().Close()
+}
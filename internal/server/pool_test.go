@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"go-template/internal/config"
+)
+
+func redisConfig(addr string) config.Redis {
+	host, port, _ := net.SplitHostPort(addr)
+	return config.Redis{
+		MaxIdle:        1,
+		IdleTimeout:    time.Minute,
+		ConnectTimeout: 5 * time.Second,
+		ReadTimeout:    5 * time.Second,
+		WriteTimeout:   5 * time.Second,
+		Host:           host,
+		Port:           port,
+	}
+}
+
+func TestPing(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	pool := newCachePool(newRedisPool(redisConfig(s.Addr())))
+	defer pool.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := ping(ctx, pool); err != nil {
+		t.Errorf("ping() error = %v", err)
+	}
+	if err := ping(context.Background(), pool); err != nil {
+		t.Errorf("ping() without deadline error = %v", err)
+	}
+}
+
+func TestPing_Deadline(t *testing.T) {
+	// accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	pool := newCachePool(newRedisPool(redisConfig(listener.Addr().String())))
+	defer pool.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := ping(ctx, pool); err == nil {
+		t.Error("ping() error = nil, want a timeout")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("ping() took %v, want the deadline of the context, not the read timeout", took)
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"go-template/internal/config"
+)
+
+// Fields that can be applied without restarting the server, keyed by their
+// mapstructure name.
+var (
+	liveHTTPFields = map[string]bool{
+		"http-server-timeout":          true,
+		"http-server-shutdown-timeout": true,
+		"http-server-shutdown-delay":   true,
+	}
+	liveRedisFields = map[string]bool{
+		"MaxIdle":     true,
+		"IdleTimeout": true,
+	}
+)
+
+// Watch subscribes the server to configuration changes. HTTP timeouts and
+// redis pool sizes are applied live, other changes are logged as requiring a
+// restart.
+func (s *Server) Watch(w *config.Watcher) {
+	w.OnHTTPChange(s.reloadHTTP)
+	w.OnGRPCChange(func(old, new config.GRPC) {
+		warnRestart("grpc", config.Diff(old, new), nil)
+	})
+	w.OnRedisChange(s.reloadRedis)
+	w.OnDatabaseChange(func(old, new config.Database) {
+		warnRestart("database", config.Diff(old, new), nil)
+	})
+}
+
+func (s *Server) reloadHTTP(old, new config.HTTP) {
+	warnRestart("http", config.Diff(old, new), liveHTTPFields)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the delay is read on shutdown, only the timeouts require a new server
+	s.http.HTTPServerShutdownDelay = new.HTTPServerShutdownDelay
+	if s.http.HTTPServerTimeout == new.HTTPServerTimeout &&
+		s.http.HTTPServerShutdownTimeout == new.HTTPServerShutdownTimeout {
+		return
+	}
+	s.http.HTTPServerTimeout = new.HTTPServerTimeout
+	s.http.HTTPServerShutdownTimeout = new.HTTPServerShutdownTimeout
+
+	// the server is not started or is shutting down
+	if s.srv == nil {
+		return
+	}
+
+	// start a server with the new timeouts on the same port, then drain the
+	// previous one
+	srv, err := s.newHTTPServer(s.http)
+	if err != nil {
+		zap.L().Warn("reload HTTP server failed", zap.Error(err))
+		return
+	}
+	prev := s.srv
+	s.srv = srv
+	s.serve(s.srv)
+	ctx, cancel := context.WithTimeout(context.Background(), s.http.HTTPServerShutdownTimeout)
+	go func() {
+		defer cancel()
+		if err := prev.Shutdown(ctx); err != nil {
+			zap.L().Warn("HTTP server graceful shutdown failed", zap.Error(err))
+		}
+	}()
+
+	zap.L().Info("HTTP server timeouts reloaded",
+		zap.Duration("http-server-timeout", s.http.HTTPServerTimeout),
+		zap.Duration("http-server-shutdown-timeout", s.http.HTTPServerShutdownTimeout),
+	)
+}
+
+func (s *Server) reloadRedis(old, new config.Redis) {
+	warnRestart("redis", config.Diff(old, new), liveRedisFields)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.redis.MaxIdle == new.MaxIdle && s.redis.IdleTimeout == new.IdleTimeout {
+		return
+	}
+	s.redis.MaxIdle = new.MaxIdle
+	s.redis.IdleTimeout = new.IdleTimeout
+
+	// the pool is not created or is closed
+	if s.pool == nil {
+		return
+	}
+
+	if err := s.pool.swap(newRedisPool(s.redis)); err != nil {
+		zap.L().Warn("close previous redis pool failed", zap.Error(err))
+	}
+
+	zap.L().Info("redis pool reloaded",
+		zap.Int("MaxIdle", s.redis.MaxIdle),
+		zap.Duration("IdleTimeout", s.redis.IdleTimeout),
+	)
+}
+
+// warnRestart logs the changed fields that can not be applied live.
+func warnRestart(section string, fields []string, live map[string]bool) {
+	for _, field := range fields {
+		if !live[field] {
+			zap.L().Warn("configuration change requires a restart",
+				zap.String("field", section+"."+field))
+		}
+	}
+}
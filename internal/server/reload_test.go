@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"go-template/internal/config"
+)
+
+// startHTTP serves handler as startServer does, on a local port whose address
+// is returned. The server is shut down at the end of the test.
+func startHTTP(t *testing.T, c config.HTTP, handler http.Handler) (*Server, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		mux:      &protocolMux{http: handler},
+		errCh:    make(chan error, 1),
+		http:     c,
+		listener: newSharedListener(listener),
+	}
+	if s.srv, err = s.newHTTPServer(s.http); err != nil {
+		t.Fatal(err)
+	}
+	s.serve(s.srv)
+	t.Cleanup(func() {
+		s.mu.Lock()
+		srv := s.srv
+		s.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		s.listener.Close()
+	})
+	return s, "http://" + listener.Addr().String()
+}
+
+func httpConfig() config.HTTP {
+	return config.HTTP{
+		Port:                      "8000",
+		HTTPServerTimeout:         time.Second,
+		HTTPServerShutdownTimeout: time.Second,
+	}
+}
+
+func TestServer_reloadHTTP_RestartOnly(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	old := httpConfig()
+	s, _ := startHTTP(t, old, http.NotFoundHandler())
+	srv := s.srv
+
+	new := old
+	new.Port = "9000"
+	new.Debug = true
+	new.HTTPServerShutdownDelay = time.Second
+	s.reloadHTTP(old, new)
+
+	if s.srv != srv {
+		t.Errorf("reloadHTTP() replaced the server, want it kept")
+	}
+	if s.http.HTTPServerShutdownDelay != time.Second {
+		t.Errorf("reloadHTTP() shutdown delay = %v, want %v", s.http.HTTPServerShutdownDelay, time.Second)
+	}
+	var restart []string
+	for _, entry := range logs.FilterMessage("configuration change requires a restart").All() {
+		restart = append(restart, entry.ContextMap()["field"].(string))
+	}
+	if want := []string{"http.port", "http.debug"}; !reflect.DeepEqual(restart, want) {
+		t.Errorf("reloadHTTP() logged restarts for %v, want %v", restart, want)
+	}
+}
+
+func TestServer_reloadHTTP_Swap(t *testing.T) {
+	// the handler blocks the requests to /slow until released
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		w.Write([]byte("ok"))
+	})
+	old := httpConfig()
+	s, url := startHTTP(t, old, handler)
+	prev := s.srv
+
+	// a request in flight on the previous server
+	slow := make(chan error, 1)
+	go func() { slow <- get(url + "/slow") }()
+	waitActive(t, s.mux, 1)
+
+	// requests keep being served while the server is replaced
+	stop := make(chan struct{})
+	traffic := make(chan error, 1)
+	go func() {
+		var err error
+		for n := 0; ; n++ {
+			select {
+			case <-stop:
+				if err == nil && n == 0 {
+					err = errors.New("no request sent")
+				}
+				traffic <- err
+				return
+			default:
+			}
+			if e := get(url + "/fast"); e != nil && err == nil {
+				err = e
+			}
+		}
+	}()
+
+	new := old
+	new.HTTPServerTimeout = 2 * time.Second
+	s.reloadHTTP(old, new)
+	if s.srv == prev {
+		t.Fatalf("reloadHTTP() kept the server, want it replaced")
+	}
+	if s.srv.WriteTimeout != 2*time.Second {
+		t.Errorf("reloadHTTP() server write timeout = %v, want 2s", s.srv.WriteTimeout)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	if err := <-traffic; err != nil {
+		t.Errorf("request during the reload failed: %v", err)
+	}
+
+	// the previous server waits for the request in flight
+	close(release)
+	if err := <-slow; err != nil {
+		t.Errorf("request in flight during the reload failed: %v", err)
+	}
+	if err := get(url + "/fast"); err != nil {
+		t.Errorf("request after the reload failed: %v", err)
+	}
+}
+
+func TestServer_reloadRedis(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	old := redisConfig(mr.Addr())
+	s := &Server{redis: old, pool: newCachePool(newRedisPool(old))}
+	defer s.pool.Close()
+	prev := s.pool.load()
+	borrowed := s.pool.Get()
+
+	// restart only changes keep the pool
+	new := old
+	new.DB = 1
+	s.reloadRedis(old, new)
+	if s.pool.load() != prev {
+		t.Fatalf("reloadRedis() replaced the pool for a restart only change")
+	}
+
+	new.MaxIdle = 5
+	s.reloadRedis(old, new)
+	if s.pool.load() == prev {
+		t.Fatalf("reloadRedis() kept the pool, want it replaced")
+	}
+	if got := s.pool.load().MaxIdle; got != 5 {
+		t.Errorf("reloadRedis() pool MaxIdle = %d, want 5", got)
+	}
+
+	// the connection borrowed from the previous pool keeps working until it
+	// is returned
+	if _, err := borrowed.Do("PING"); err != nil {
+		t.Errorf("PING on a connection of the previous pool error = %v", err)
+	}
+	borrowed.Close()
+	if n := prev.ActiveCount(); n != 0 {
+		t.Errorf("previous pool has %d active connections, want 0", n)
+	}
+
+	conn := s.pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		t.Errorf("PING on the new pool error = %v", err)
+	}
+}
+
+// get requests url and fails unless it answers 200.
+func get(url string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s status = %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// waitActive waits until n requests are in flight in mux.
+func waitActive(t *testing.T, mux *protocolMux, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&mux.active) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d requests in flight, want %d", atomic.LoadInt64(&mux.active), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
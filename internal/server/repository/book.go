@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"fmt"
+	"go-template/internal/changefeed"
+	"go-template/internal/server/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type bookRepo struct {
+	db   *sqlx.DB
+	feed *changefeed.Feed
+}
+
+// NewBookRepo returns a book repository publishing its changes to feed,
+// which may be nil.
+func NewBookRepo(db *sqlx.DB, feed *changefeed.Feed) *bookRepo {
+	return &bookRepo{
+		db:   db,
+		feed: feed,
+	}
+}
+
+func (r *bookRepo) Get(bookID string) (*model.Book, error) {
+	query := `SELECT id, name FROM book where id = ?`
+	book := model.Book{}
+	if err := r.db.Get(&book, query, bookID); err != nil {
+		return nil, fmt.Errorf("Get Book failed. bookId: %v, error: %w", bookID, err)
+	}
+	return &book, nil
+}
+
+func (r *bookRepo) Create(book *model.Book) error {
+	query := `INSERT INTO book (id, name) VALUES (?, ?)`
+	if _, err := r.db.Exec(query, book.ID, book.Name); err != nil {
+		return fmt.Errorf("Create Book failed. bookId: %v, error: %w", book.ID, duplicate(err))
+	}
+	r.feed.Publish(changefeed.KindBook, changefeed.Created, book.ID, book)
+	return nil
+}
+
+func (r *bookRepo) Update(book *model.Book) error {
+	query := `UPDATE book SET name = ? WHERE id = ?`
+	if err := exec(r.db, query, book.Name, book.ID); err != nil {
+		return fmt.Errorf("Update Book failed. bookId: %v, error: %w", book.ID, err)
+	}
+	r.feed.Publish(changefeed.KindBook, changefeed.Updated, book.ID, book)
+	return nil
+}
+
+func (r *bookRepo) Delete(bookID string) error {
+	query := `DELETE FROM book WHERE id = ?`
+	if err := exec(r.db, query, bookID); err != nil {
+		return fmt.Errorf("Delete Book failed. bookId: %v, error: %w", bookID, err)
+	}
+	r.feed.Publish(changefeed.KindBook, changefeed.Deleted, bookID, nil)
+	return nil
+}
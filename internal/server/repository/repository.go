@@ -2,12 +2,20 @@ package repository
 
 import "go-template/internal/server/model"
 
-// UserRepo is an interface to access user table
+// UserRepo is an interface to access user table. Update and Delete return
+// an error wrapping sql.ErrNoRows if the user does not exist. The changes
+// are published to the change feed of the repository.
 type UserRepo interface {
 	Get(userID string) (*model.User, error)
+	Create(user *model.User) error
+	Update(user *model.User) error
+	Delete(userID string) error
 }
 
-// BookRepo is an interface to access book table
+// BookRepo is an interface to access book table, see UserRepo.
 type BookRepo interface {
 	Get(bookID string) (*model.Book, error)
+	Create(book *model.Book) error
+	Update(book *model.Book) error
+	Delete(bookID string) error
 }
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"go-template/internal/changefeed"
+	"go-template/internal/server/model"
+)
+
+// fakeDriver is a database/sql driver whose statements affect the rows or
+// fail as set by the test.
+type fakeDriver struct {
+	mu       sync.Mutex
+	affected int64
+	err      error
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+func (d *fakeDriver) set(affected int64, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.affected, d.err = affected, err
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt(c), nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.err != nil {
+		return nil, s.d.err
+	}
+	return driver.RowsAffected(s.d.affected), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+var fake = &fakeDriver{}
+
+func init() {
+	sql.Register("repository-fake", fake)
+}
+
+func TestRepositories_PublishWrites(t *testing.T) {
+	db, err := sqlx.Open("repository-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	feed := changefeed.New(16)
+	defer feed.Close()
+	sub, err := feed.Subscribe("")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	users, books := NewUserRepo(db, feed), NewBookRepo(db, feed)
+
+	steps := []struct {
+		name     string
+		affected int64
+		err      error
+		write    func() error
+		wantErr  error
+		// the event published, none if Kind is empty
+		want changefeed.Event
+	}{
+		{
+			name:     "create user",
+			affected: 1,
+			write:    func() error { return users.Create(&model.User{ID: "1", Name: "A"}) },
+			want:     changefeed.Event{Kind: changefeed.KindUser, Type: changefeed.Created, ID: "1", Object: &model.User{ID: "1", Name: "A"}},
+		},
+		{
+			name:    "create a taken user id",
+			err:     &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"},
+			write:   func() error { return users.Create(&model.User{ID: "1", Name: "A"}) },
+			wantErr: ErrAlreadyExists,
+		},
+		{
+			name:     "update user",
+			affected: 1,
+			write:    func() error { return users.Update(&model.User{ID: "1", Name: "B"}) },
+			want:     changefeed.Event{Kind: changefeed.KindUser, Type: changefeed.Updated, ID: "1", Object: &model.User{ID: "1", Name: "B"}},
+		},
+		{
+			name:    "update a missing user",
+			write:   func() error { return users.Update(&model.User{ID: "2", Name: "B"}) },
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name:     "delete book",
+			affected: 1,
+			write:    func() error { return books.Delete("1") },
+			want:     changefeed.Event{Kind: changefeed.KindBook, Type: changefeed.Deleted, ID: "1"},
+		},
+		{
+			name:    "database failure",
+			err:     errors.New("connection refused"),
+			write:   func() error { return books.Create(&model.Book{ID: "1", Name: "A"}) },
+			wantErr: errors.New("connection refused"),
+		},
+	}
+	for _, step := range steps {
+		fake.set(step.affected, step.err)
+		err := step.write()
+		switch {
+		case step.wantErr == nil && err != nil:
+			t.Fatalf("%s: error = %v", step.name, err)
+		case step.wantErr != nil && err == nil:
+			t.Fatalf("%s: error = nil, want %v", step.name, step.wantErr)
+		case step.wantErr == ErrAlreadyExists || step.wantErr == sql.ErrNoRows:
+			if !errors.Is(err, step.wantErr) {
+				t.Errorf("%s: error = %v, want %v", step.name, err, step.wantErr)
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		event, err := sub.Next(ctx)
+		cancel()
+		if step.want.Kind == "" {
+			if err == nil {
+				t.Errorf("%s: published %+v, want no event", step.name, event)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: no event published, Next() error = %v", step.name, err)
+		}
+		event.Token, event.Time = "", time.Time{}
+		if !reflect.DeepEqual(event, step.want) {
+			t.Errorf("%s: published %+v, want %+v", step.name, event, step.want)
+		}
+	}
+}
@@ -1,19 +1,27 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"go-template/internal/changefeed"
 	"go-template/internal/server/model"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
 type userRepo struct {
-	db *sqlx.DB
+	db   *sqlx.DB
+	feed *changefeed.Feed
 }
 
-func NewUserRepo(db *sqlx.DB) *userRepo {
+// NewUserRepo returns a user repository publishing its changes to feed,
+// which may be nil.
+func NewUserRepo(db *sqlx.DB, feed *changefeed.Feed) *userRepo {
 	return &userRepo{
-		db: db,
+		db:   db,
+		feed: feed,
 	}
 }
 
@@ -25,3 +33,64 @@ func (r *userRepo) Get(userID string) (*model.User, error) {
 	}
 	return &user, nil
 }
+
+func (r *userRepo) Create(user *model.User) error {
+	query := `INSERT INTO user (id, name) VALUES (?, ?)`
+	if _, err := r.db.Exec(query, user.ID, user.Name); err != nil {
+		return fmt.Errorf("Create User failed. userId: %v, error: %w", user.ID, duplicate(err))
+	}
+	r.feed.Publish(changefeed.KindUser, changefeed.Created, user.ID, user)
+	return nil
+}
+
+func (r *userRepo) Update(user *model.User) error {
+	query := `UPDATE user SET name = ? WHERE id = ?`
+	if err := exec(r.db, query, user.Name, user.ID); err != nil {
+		return fmt.Errorf("Update User failed. userId: %v, error: %w", user.ID, err)
+	}
+	r.feed.Publish(changefeed.KindUser, changefeed.Updated, user.ID, user)
+	return nil
+}
+
+func (r *userRepo) Delete(userID string) error {
+	query := `DELETE FROM user WHERE id = ?`
+	if err := exec(r.db, query, userID); err != nil {
+		return fmt.Errorf("Delete User failed. userId: %v, error: %w", userID, err)
+	}
+	r.feed.Publish(changefeed.KindUser, changefeed.Deleted, userID, nil)
+	return nil
+}
+
+// ErrAlreadyExists is wrapped by the errors of Create when the id is taken.
+var ErrAlreadyExists = errors.New("already exists")
+
+// duplicate returns ErrAlreadyExists if err is a duplicate key error of
+// MySQL, err otherwise.
+func duplicate(err error) error {
+	var e *mysql.MySQLError
+	if errors.As(err, &e) && e.Number == mysqlDuplicateEntry {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// mysqlDuplicateEntry is the MySQL error number of duplicate keys,
+// ER_DUP_ENTRY.
+const mysqlDuplicateEntry = 1062
+
+// exec executes a statement changing a single row, sql.ErrNoRows is returned
+// if the row does not exist.
+func exec(db *sqlx.DB, query string, args ...interface{}) error {
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
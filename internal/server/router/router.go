@@ -1,29 +1,58 @@
 package router
 
 import (
+	"go-template/internal/changefeed"
 	"go-template/internal/server/api"
+	"go-template/internal/server/cache"
 	"go-template/internal/server/middleware"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gomodule/redigo/redis"
 	"github.com/jmoiron/sqlx"
 )
 
-// New returns a http.Handler
-func New(pool *redis.Pool, db *sqlx.DB) http.Handler {
+// Gateway is a handler served under Prefix, e.g. the REST gateway of the
+// gRPC services.
+type Gateway struct {
+	Prefix  string
+	Handler http.Handler
+}
+
+// Options are the optional parts of the router.
+type Options struct {
+	// Gateway, if not nil, goes through the same middlewares as the other
+	// routes.
+	Gateway *Gateway
+	// Errors configures the rendering of the errors, see middleware.Errors.
+	Errors middleware.ErrorsConfig
+}
+
+// New returns a http.Handler.
+func New(pool cache.Pool, db *sqlx.DB, feed *changefeed.Feed, opts Options) http.Handler {
 	gin.SetMode(gin.ReleaseMode)
-	r := gin.Default()
+	r := gin.New()
 
 	// RequestID middleware must be registered at the beginning.
 	r.Use(middleware.RequestID())
-	r.Use(gin.Recovery())
+	r.Use(middleware.ClientIdentity())
 	r.Use(middleware.Logger())
+	// Prometheus before Recovery, so that panics are measured as the 500
+	// responses Recovery renders.
 	r.Use(middleware.Prometheus())
+	r.Use(gin.Recovery())
 	r.Use(middleware.Version())
+	// Errors renders the errors of the handlers, after the middlewares above
+	// so that they log and measure the rendered response.
+	r.Use(middleware.Errors(opts.Errors))
 
-	userAPI := api.NewUserAPI(pool, db)
+	userAPI := api.NewUserAPI(pool, db, feed)
+	watchAPI := api.NewWatchAPI(feed)
 	// r.GET("/", api.Index.Healthy(env))
 	r.GET("/users", userAPI.Get)
+	r.GET("/events", watchAPI.Watch)
+
+	if g := opts.Gateway; g != nil {
+		r.Any(g.Prefix+"/*path", gin.WrapH(http.StripPrefix(g.Prefix, g.Handler)))
+	}
 	return r
 }
@@ -3,130 +3,454 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
 	"github.com/gomodule/redigo/redis"
 	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 
+	"go-template/internal/certs"
+	"go-template/internal/changefeed"
 	"go-template/internal/config"
+	"go-template/internal/database"
+	"go-template/internal/grpc"
+	"go-template/internal/health"
+	"go-template/internal/lifecycle"
+	"go-template/internal/server/cache"
+	"go-template/internal/server/middleware"
 	"go-template/internal/server/router"
 )
 
+const (
+	// healthCheckTimeout bounds each dependency check of the readiness probe.
+	healthCheckTimeout = 2 * time.Second
+	// healthCacheTTL is how long check results are reused between probes.
+	healthCacheTTL = time.Second
+	// grpcHealthInterval is how often the gRPC health status is updated
+	// from the readiness checks.
+	grpcHealthInterval = time.Second
+	// changeFeedSize is the number of changes kept for watchers to resume
+	// after.
+	changeFeedSize = 1024
+)
+
 // Server is a HTTP server
 type Server struct {
-	config *config.Config
-	router http.Handler
+	config    *config.Config
+	router    http.Handler
+	mux       *protocolMux
+	grpc      *grpc.Server
+	lifecycle *lifecycle.Manager
+	health    *health.Registry
+	feed      *changefeed.Feed
+	// errCh receives the errors of components failing after they started.
+	errCh chan error
+
+	// mu guards the fields below, which are changed when the configuration
+	// file is reloaded.
+	mu       sync.Mutex
+	http     config.HTTP
+	redis    config.Redis
+	listener *sharedListener
+	certs    *certs.Reloader
+	srv      *http.Server
+	pool     *cachePool
+	db       *sqlx.DB
 }
 
-// NewServer return a HTTP server
+// NewServer return a HTTP and gRPC server
 func NewServer(config *config.Config) (*Server, error) {
+	grpcServer, err := grpc.NewServer(config.GRPC, zap.L())
+	if err != nil {
+		return nil, err
+	}
+
 	srv := &Server{
-		config: config,
+		grpc:      grpcServer,
+		config:    config,
+		lifecycle: lifecycle.NewManager(),
+		health:    health.NewRegistry(healthCacheTTL),
+		feed:      changefeed.New(changeFeedSize),
+		errCh:     make(chan error, 1),
+		http:      config.HTTP,
+		redis:     config.Redis,
+	}
+
+	// components start in dependency order and stop in reverse order
+	redisComponent, dbComponent := srv.cacheComponent(), srv.databaseComponent()
+	srv.lifecycle.Add(srv.metricsComponent())
+	srv.lifecycle.Add(redisComponent)
+	srv.lifecycle.Add(dbComponent)
+	srv.lifecycle.Add(srv.grpcComponent(), "redis", "database")
+	srv.lifecycle.Add(srv.httpComponent(), "redis", "database", "grpc")
+	srv.lifecycle.Add(lifecycle.NewWorker("grpc-health", srv.updateGRPCHealth), "grpc")
+
+	// the server is ready when its dependencies are
+	for _, c := range []lifecycle.Component{redisComponent, dbComponent} {
+		srv.health.RegisterReadiness(c.Name(), health.CheckerFunc(c.Health), healthCheckTimeout)
 	}
 	return srv, nil
 }
 
-// Run starts HTTP server and watches channel to determine whether to stop server gracefully.
-func (s *Server) Run(stopCh <-chan struct{}) {
-	go s.startMetricsServer()
-
-	// create redis pool, connect database, etc.
-	pool, err := s.startCachePool()
-	if err != nil {
-		zap.L().Fatal(fmt.Sprintf("connect to redis failed: %v\n", err.Error()))
+// Run starts the server components and watches channel to determine whether
+// to stop them gracefully. The components are also stopped when one of them
+// fails. The errors of starting, running and stopping are returned.
+func (s *Server) Run(stopCh <-chan struct{}) error {
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
 	}
 
-	db, err := s.connectDatabase()
-	if err != nil {
-		zap.L().Fatal(fmt.Sprintf("connect to database failed: %v\n", err.Error()))
+	// wait for SIGTERM or SIGINT
+	var runErr error
+	select {
+	case <-stopCh:
+	case runErr = <-s.errCh:
+		zap.L().Error("server component failed, shutting down", zap.Error(runErr))
 	}
 
-	// register http handlers
-	s.registerHandlers(pool, db)
+	// report not ready, and keep serving until the load balancers notice it
+	s.health.ShutDown()
+	s.grpc.ShutdownHealth()
 
-	// create http server
-	srv := s.startServer()
+	s.mu.Lock()
+	delay, timeout := s.http.HTTPServerShutdownDelay, s.http.HTTPServerShutdownTimeout
+	s.mu.Unlock()
+	if delay > 0 {
+		zap.L().Info("Waiting for the readiness to propagate", zap.Duration("delay", delay))
+		time.Sleep(delay)
+	}
 
-	// wait for SIGTERM or SIGINT
-	<-stopCh
-	ctx, cancel := context.WithTimeout(context.Background(), s.config.HTTP.HTTPServerShutdownTimeout)
+	// end the event streams which would hold the draining
+	s.feed.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// close cache pool
-	if pool != nil {
-		_ = pool.Close()
-	}
+	zap.L().Info("Shutting down server", zap.Duration("timeout", timeout))
+	return multierr.Append(runErr, s.lifecycle.Stop(ctx))
+}
 
-	zap.L().Info("Shutting down HTTP/HTTPS server", zap.Duration("timeout", s.config.HTTP.HTTPServerShutdownTimeout))
+// fail reports the failure of a running component to Run.
+func (s *Server) fail(err error) {
+	select {
+	case s.errCh <- err:
+	default:
+		// a failure is already reported
+		zap.L().Error("server component failed", zap.Error(err))
+	}
+}
 
-	// determine if the http server was started
-	if srv != nil {
-		if err := srv.Shutdown(ctx); err != nil {
-			zap.L().Warn("HTTP server graceful shutdown failed", zap.Error(err))
+func (s *Server) registerHandlers(ctx context.Context, pool cache.Pool, db *sqlx.DB) error {
+	opts := router.Options{Errors: middleware.ErrorsConfig{
+		Debug:           s.config.HTTP.Debug,
+		Problem:         s.config.HTTP.Errors.Format == "problem",
+		ProblemTypeBase: s.config.HTTP.Errors.TypeBase,
+	}}
+	if c := s.config.GRPC.Gateway; c.Enabled {
+		handler, err := s.grpc.Gateway(ctx, middleware.ReportError)
+		if err != nil {
+			return fmt.Errorf("create gRPC gateway failed: %w", err)
 		}
+		opts.Gateway = &router.Gateway{Prefix: c.Prefix, Handler: handler}
+	}
+
+	s.router = router.New(pool, db, s.feed, opts)
+	s.mux = &protocolMux{http: s.router}
+	// serve gRPC on the HTTP port unless it has a port of its own
+	if s.config.GRPC.Port == 0 {
+		s.mux.grpc = s.grpc
 	}
+	return nil
 }
 
-func (s *Server) registerHandlers(pool *redis.Pool, db *sqlx.DB) {
-	s.router = router.New(pool, db)
+func (s *Server) httpComponent() lifecycle.Component {
+	return &lifecycle.Hook{
+		ComponentName: "http",
+		OnStart: func(ctx context.Context) error {
+			s.mu.Lock()
+			pool, db := s.pool, s.db
+			s.mu.Unlock()
+
+			// register http handlers
+			if err := s.registerHandlers(ctx, pool, db); err != nil {
+				return err
+			}
+			return s.startServer()
+		},
+		OnStop: func(ctx context.Context) error {
+			// stop applying configuration changes
+			s.mu.Lock()
+			srv := s.srv
+			s.srv = nil
+			s.mu.Unlock()
+
+			// determine if the http server was started
+			if srv == nil {
+				return nil
+			}
+			zap.L().Info("Shutting down HTTP/HTTPS server")
+			err := srv.Shutdown(ctx)
+			// wait for the requests of h2c connections, which Shutdown does
+			// not track, then cancel the RPCs still running
+			err = multierr.Append(err, s.mux.drain(ctx))
+			if s.mux.grpc != nil {
+				s.mux.grpc.Stop()
+			}
+			err = multierr.Append(err, s.listener.Close())
+			if s.certs != nil {
+				err = multierr.Append(err, s.certs.Close())
+			}
+			return err
+		},
+	}
 }
 
-func (s *Server) startServer() *http.Server {
+func (s *Server) startServer() error {
 	// determine if the port is specified
 	c := s.config
 	if c.HTTP.Port == "0" {
 		return nil
 	}
 
+	listener, err := net.Listen("tcp", ":"+c.HTTP.Port)
+	if err != nil {
+		return err
+	}
+	tlsListener, reloader, err := listenTLS(listener, c.HTTP.TLS)
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("configure TLS failed: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.certs = reloader
+	s.listener = newSharedListener(tlsListener)
+	if s.srv, err = s.newHTTPServer(s.http); err != nil {
+		s.listener.Close()
+		return err
+	}
+	s.serve(s.srv)
+	return nil
+}
+
+// newHTTPServer returns a server for the HTTP and gRPC requests, over
+// HTTP/1.1 or HTTP/2 with or without TLS.
+func (s *Server) newHTTPServer(c config.HTTP) (*http.Server, error) {
 	srv := &http.Server{
-		Addr:         ":" + c.HTTP.Port,
-		WriteTimeout: c.HTTP.HTTPServerTimeout,
-		ReadTimeout:  c.HTTP.HTTPServerTimeout,
-		IdleTimeout:  2 * c.HTTP.HTTPServerShutdownTimeout,
-		Handler:      s.router,
+		WriteTimeout: c.HTTPServerTimeout,
+		ReadTimeout:  c.HTTPServerTimeout,
+		IdleTimeout:  2 * c.HTTPServerShutdownTimeout,
+		Handler:      s.mux,
+		// let the event streams end before their response is cut
+		ConnContext: func(ctx context.Context, _ net.Conn) context.Context {
+			return changefeed.WithWriteTimeout(ctx, c.HTTPServerTimeout)
+		},
+	}
+	if err := withH2C(srv); err != nil {
+		return nil, err
 	}
+	return srv, nil
+}
 
-	// start the server in the background
+// serve starts the server in the background.
+func (s *Server) serve(srv *http.Server) {
+	l := s.listener.view()
 	go func() {
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-			zap.L().Fatal("HTTP server crashed", zap.Error(err))
+		if err := srv.Serve(l); err != http.ErrServerClosed {
+			s.fail(fmt.Errorf("HTTP server crashed: %w", err))
 		}
 	}()
+}
 
-	return srv
+// grpcComponent registers the gRPC services and serves them on their own
+// port, if one is configured. It is stopped gracefully, the pending RPCs are
+// cancelled when the shutdown timeout expires.
+func (s *Server) grpcComponent() lifecycle.Component {
+	var started bool
+	return &lifecycle.Hook{
+		ComponentName: "grpc",
+		OnStart: func(ctx context.Context) error {
+			s.mu.Lock()
+			pool, db := s.pool, s.db
+			s.mu.Unlock()
+			s.grpc.RegisterServices(pool, db, s.feed)
+
+			if s.config.GRPC.Port == 0 {
+				return nil
+			}
+			listener, err := s.grpc.Listen()
+			if err != nil {
+				return err
+			}
+			started = true
+			go func() {
+				if err := s.grpc.Serve(listener); err != nil {
+					s.fail(fmt.Errorf("gRPC server crashed: %w", err))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if !started {
+				return nil
+			}
+			zap.L().Info("Shutting down gRPC server")
+			return s.grpc.Shutdown(ctx)
+		},
+	}
 }
 
-func (s *Server) startMetricsServer() {
-	if s.config.HTTP.PortMetrics > 0 {
-		mux := http.DefaultServeMux
-		mux.Handle("/metrics", promhttp.Handler())
-		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("OK"))
-		})
+// updateGRPCHealth sets the gRPC health status from the readiness checks
+// until ctx is cancelled, so that it follows the state of the dependencies.
+func (s *Server) updateGRPCHealth(ctx context.Context) error {
+	ticker := time.NewTicker(grpcHealthInterval)
+	defer ticker.Stop()
+
+	// the server starts not serving
+	serving := false
+	for {
+		report := s.health.Readiness(ctx)
+		if report.OK() != serving {
+			serving = report.OK()
+			zap.L().Info("gRPC health status changed", zap.Bool("serving", serving), zap.Any("checks", report.Checks))
+			s.grpc.SetServing(serving)
+		}
 
-		srv := &http.Server{
-			Addr:    fmt.Sprintf(":%v", s.config.HTTP.PortMetrics),
-			Handler: mux,
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
-		srv.ListenAndServe()
 	}
 }
 
-func (s *Server) startCachePool() (*redis.Pool, error) {
-	c := s.config.Redis
+func (s *Server) metricsComponent() lifecycle.Component {
+	var srv *http.Server
+	return &lifecycle.Hook{
+		ComponentName: "metrics",
+		OnStart: func(ctx context.Context) error {
+			if s.config.HTTP.PortMetrics <= 0 {
+				return nil
+			}
+			listener, err := net.Listen("tcp", fmt.Sprintf(":%v", s.config.HTTP.PortMetrics))
+			if err != nil {
+				return err
+			}
+			srv = s.newMetricsServer()
+			go func() {
+				if err := srv.Serve(listener); err != http.ErrServerClosed {
+					s.fail(fmt.Errorf("metrics server crashed: %w", err))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if srv == nil {
+				return nil
+			}
+			return srv.Shutdown(ctx)
+		},
+	}
+}
+
+func (s *Server) newMetricsServer() *http.Server {
+	registerBuildInfo(prometheus.DefaultRegisterer)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/version", versionHandler)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	mux.Handle("/livez", s.health.LivezHandler())
+	mux.Handle("/readyz", s.health.ReadyzHandler())
+
+	return &http.Server{
+		Handler: mux,
+	}
+}
+
+func (s *Server) cacheComponent() lifecycle.Component {
+	return &lifecycle.Hook{
+		ComponentName: "redis",
+		OnStart: func(ctx context.Context) error {
+			if _, err := s.startCachePool(ctx); err != nil {
+				return fmt.Errorf("connect to redis failed: %w", err)
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			// stop applying configuration changes
+			s.mu.Lock()
+			pool := s.pool
+			s.pool = nil
+			s.mu.Unlock()
+
+			if pool == nil {
+				return nil
+			}
+			return pool.Close()
+		},
+		OnHealth: func(ctx context.Context) error {
+			s.mu.Lock()
+			pool := s.pool
+			s.mu.Unlock()
+
+			if pool == nil {
+				return fmt.Errorf("redis pool is closed")
+			}
+			return ping(ctx, pool)
+		},
+	}
+}
 
-	pool := &redis.Pool{
+func (s *Server) startCachePool(ctx context.Context) (*cachePool, error) {
+	pool := newCachePool(newRedisPool(s.config.Redis))
+
+	s.mu.Lock()
+	s.pool = pool
+	s.mu.Unlock()
+
+	// test connection pool
+	return pool, ping(ctx, pool)
+}
+
+// ping checks a connection of pool, until ctx is done at most.
+func ping(ctx context.Context, pool *cachePool) error {
+	conn, err := pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		_, err = conn.Do("PING")
+		return err
+	}
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return ctx.Err()
+	}
+	_, err = redis.DoWithTimeout(conn, timeout, "PING")
+	return err
+}
+
+func newRedisPool(c config.Redis) *redis.Pool {
+	return &redis.Pool{
 		MaxIdle:     c.MaxIdle,
-		IdleTimeout: c.IdleTimeout * time.Second,
+		IdleTimeout: c.IdleTimeout,
 		Wait:        true,
-		Dial: func() (redis.Conn, error) {
-			return redis.Dial(
-				"tcp", fmt.Sprintf("%s:%s", c.Host, c.Port),
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			return redis.DialContext(
+				ctx, "tcp", fmt.Sprintf("%s:%s", c.Host, c.Port),
 				redis.DialPassword(c.Password),
 				redis.DialDatabase(c.DB),
 				redis.DialConnectTimeout(c.ConnectTimeout),
@@ -135,32 +459,40 @@ func (s *Server) startCachePool() (*redis.Pool, error) {
 			)
 		},
 	}
+}
 
-	// test connection pool
-	conn := pool.Get()
-	defer conn.Close()
+func (s *Server) databaseComponent() lifecycle.Component {
+	return &lifecycle.Hook{
+		ComponentName: "database",
+		OnStart: func(ctx context.Context) error {
+			db, err := s.connectDatabase()
+			if err != nil {
+				return fmt.Errorf("connect to database failed: %w", err)
+			}
+			s.mu.Lock()
+			s.db = db
+			s.mu.Unlock()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			s.mu.Lock()
+			db := s.db
+			s.mu.Unlock()
+			return db.Close()
+		},
+		OnHealth: func(ctx context.Context) error {
+			s.mu.Lock()
+			db := s.db
+			s.mu.Unlock()
 
-	if _, err := conn.Do("PING"); err != nil {
-		return pool, err
+			if db == nil {
+				return fmt.Errorf("database is not connected")
+			}
+			return db.PingContext(ctx)
+		},
 	}
-
-	return pool, nil
 }
 
 func (s *Server) connectDatabase() (*sqlx.DB, error) {
-	c := s.config.Database
-	config := &mysql.Config{
-		User:                 c.User,
-		Passwd:               c.Password,
-		DBName:               c.DBName,
-		Net:                  "tcp",
-		Addr:                 fmt.Sprintf("%s:%s", c.Host, c.Port),
-		Loc:                  time.Local,
-		Timeout:              c.Timeout,
-		ReadTimeout:          c.ReadTimeout,
-		WriteTimeout:         c.WriteTimeout,
-		AllowNativePasswords: true,
-		CheckConnLiveness:    true,
-	}
-	return sqlx.Connect("mysql", config.FormatDSN())
+	return database.Connect(s.config.Database)
 }
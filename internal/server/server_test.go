@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-template/internal/changefeed"
+	"go-template/internal/config"
+	"go-template/internal/grpc"
+	"go-template/internal/health"
+	"go-template/internal/lifecycle"
+)
+
+func TestServer_Run_ShutdownDelay(t *testing.T) {
+	const delay = 300 * time.Millisecond
+
+	grpcServer, err := grpc.NewServer(config.GRPC{ServiceName: "test"}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		grpc:      grpcServer,
+		lifecycle: lifecycle.NewManager(),
+		health:    health.NewRegistry(0),
+		feed:      changefeed.New(1),
+		errCh:     make(chan error, 1),
+		http:      config.HTTP{HTTPServerShutdownTimeout: time.Second, HTTPServerShutdownDelay: delay},
+	}
+	s.health.RegisterReadiness("always", health.CheckerFunc(func(context.Context) error { return nil }), time.Second)
+
+	// serve the readiness probe until the server stops
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: s.health.ReadyzHandler()}
+	stopped := make(chan time.Time, 1)
+	s.lifecycle.Add(&lifecycle.Hook{
+		ComponentName: "http",
+		OnStart: func(ctx context.Context) error {
+			go srv.Serve(listener)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopped <- time.Now()
+			return srv.Shutdown(ctx)
+		},
+	})
+
+	stopCh := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- s.Run(stopCh) }()
+
+	url := "http://" + listener.Addr().String()
+	if status := probe(t, url); status != http.StatusOK {
+		t.Fatalf("/readyz status before the shutdown = %d, want 200", status)
+	}
+	start := time.Now()
+	close(stopCh)
+	for {
+		status := probe(t, url)
+		if status == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Since(start) > delay {
+			t.Fatalf("/readyz status during the shutdown delay = %d, want 503", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := (<-stopped).Sub(start); got < delay {
+		t.Errorf("listeners closed %v after the shutdown began, want after %v", got, delay)
+	}
+}
+
+// probeClient closes its connections, which would otherwise hold the shutdown
+// of the server.
+var probeClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+// probe returns the status of GET url, it fails the test if the request fails.
+func probe(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := probeClient.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServer_newMetricsServer(t *testing.T) {
+	// servers built in the same process share the registry
+	for i := 0; i < 2; i++ {
+		s := &Server{health: health.NewRegistry(0)}
+		srv := s.newMetricsServer()
+
+		for _, path := range []string{"/metrics", "/version", "/readyz"} {
+			w := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("server %d: GET %s status = %d, want 200", i, path, w.Code)
+			}
+			if path == "/metrics" && !strings.Contains(w.Body.String(), "app_build_info{") {
+				t.Errorf("server %d: GET /metrics does not report app_build_info", i)
+			}
+		}
+	}
+}
@@ -14,6 +14,18 @@ func (s *bookService) Get(ctx context.Context, bookID string) (*model.Book, erro
 	return s.repo.Get(bookID)
 }
 
+func (s *bookService) Create(ctx context.Context, book *model.Book) error {
+	return s.repo.Create(book)
+}
+
+func (s *bookService) Update(ctx context.Context, book *model.Book) error {
+	return s.repo.Update(book)
+}
+
+func (s *bookService) Delete(ctx context.Context, bookID string) error {
+	return s.repo.Delete(bookID)
+}
+
 // NewBookService returns a BookService instance.
 func NewBookService(repo repository.BookRepo) BookService {
 	return &bookService{
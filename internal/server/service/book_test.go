@@ -37,6 +37,18 @@ func (r *mockBookRepo) Get(bookID string) (*model.Book, error) {
 	return book, errors.New("Not Found")
 }
 
+func (r *mockBookRepo) Create(book *model.Book) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (r *mockBookRepo) Update(book *model.Book) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (r *mockBookRepo) Delete(bookID string) error {
+	panic("not implemented") // TODO: Implement
+}
+
 func Test_bookService_Get(t *testing.T) {
 	type fields struct {
 		repo repository.BookRepo
@@ -8,9 +8,15 @@ import (
 // UserService interface
 type UserService interface {
 	Get(ctx context.Context, userID string) (*model.User, error)
+	Create(ctx context.Context, user *model.User) error
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, userID string) error
 }
 
 // BookService interface
 type BookService interface {
 	Get(ctx context.Context, bookID string) (*model.Book, error)
+	Create(ctx context.Context, book *model.Book) error
+	Update(ctx context.Context, book *model.Book) error
+	Delete(ctx context.Context, bookID string) error
 }
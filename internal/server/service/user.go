@@ -35,3 +35,27 @@ func (s *userService) Get(ctx context.Context, userID string) (*model.User, erro
 	}
 	return user, nil
 }
+
+// Create creates the user and caches it, as users are read from the cache.
+func (s *userService) Create(ctx context.Context, user *model.User) error {
+	if err := s.repo.Create(user); err != nil {
+		return err
+	}
+	return s.cache.Set(user.ID, &model.UserCache{ID: user.ID, Name: user.Name})
+}
+
+// Update updates the user and its cached copy.
+func (s *userService) Update(ctx context.Context, user *model.User) error {
+	if err := s.repo.Update(user); err != nil {
+		return err
+	}
+	return s.cache.Set(user.ID, &model.UserCache{ID: user.ID, Name: user.Name})
+}
+
+// Delete deletes the user and its cached copy.
+func (s *userService) Delete(ctx context.Context, userID string) error {
+	if err := s.repo.Delete(userID); err != nil {
+		return err
+	}
+	return s.cache.Delete(userID)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"go-template/internal/server/cache"
 	"go-template/internal/server/model"
@@ -46,6 +47,18 @@ func (r *mockUserRepo) Get(userID string) (*model.User, error) {
 	return nil, errors.New("Not Found")
 }
 
+func (r *mockUserRepo) Create(user *model.User) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (r *mockUserRepo) Update(user *model.User) error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (r *mockUserRepo) Delete(userID string) error {
+	panic("not implemented") // TODO: Implement
+}
+
 type mockUserCache struct{}
 
 func (c *mockUserCache) Get(userID string) (*model.UserCache, error) {
@@ -64,6 +77,60 @@ func (c *mockUserCache) Set(userID string, user *model.UserCache) error {
 	panic("not implemented") // TODO: Implement
 }
 
+func (c *mockUserCache) Delete(userID string) error {
+	panic("not implemented") // TODO: Implement
+}
+
+// memoryUserRepo and memoryUserCache keep the users in maps.
+type memoryUserRepo map[string]model.User
+
+func (r memoryUserRepo) Get(userID string) (*model.User, error) {
+	user, ok := r[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &user, nil
+}
+
+func (r memoryUserRepo) Create(user *model.User) error {
+	r[user.ID] = *user
+	return nil
+}
+
+func (r memoryUserRepo) Update(user *model.User) error {
+	if _, ok := r[user.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	r[user.ID] = *user
+	return nil
+}
+
+func (r memoryUserRepo) Delete(userID string) error {
+	if _, ok := r[userID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r, userID)
+	return nil
+}
+
+type memoryUserCache map[string]model.UserCache
+
+func (c memoryUserCache) Get(userID string) (*model.UserCache, error) {
+	// a missing user reads as an empty one, like in redis
+	user := c[userID]
+	return &user, nil
+}
+
+func (c memoryUserCache) Set(userID string, user *model.UserCache) error {
+	c[userID] = *user
+	return nil
+}
+
+func (c memoryUserCache) Delete(userID string) error {
+	delete(c, userID)
+	return nil
+}
+
 func Test_userService_Get(t *testing.T) {
 	type fields struct {
 		repo  repository.UserRepo
@@ -117,3 +184,37 @@ func Test_userService_Get(t *testing.T) {
 		})
 	}
 }
+
+func Test_userService_Write(t *testing.T) {
+	repo, cache := memoryUserRepo{}, memoryUserCache{}
+	s := NewUserService(repo, cache)
+	ctx := context.TODO()
+
+	if err := s.Create(ctx, &model.User{ID: "1", Name: "A"}); err != nil {
+		t.Fatalf("userService.Create() error = %v", err)
+	}
+	if err := s.Update(ctx, &model.User{ID: "1", Name: "B"}); err != nil {
+		t.Fatalf("userService.Update() error = %v", err)
+	}
+	got, err := s.Get(ctx, "1")
+	if err != nil || !reflect.DeepEqual(got, &model.User{ID: "1", Name: "B"}) {
+		t.Errorf("userService.Get() after Update = %v, %v, want the updated user", got, err)
+	}
+	if !reflect.DeepEqual(repo["1"], model.User{ID: "1", Name: "B"}) {
+		t.Errorf("repository has %v, want the updated user", repo["1"])
+	}
+
+	if err := s.Delete(ctx, "1"); err != nil {
+		t.Fatalf("userService.Delete() error = %v", err)
+	}
+	if got, _ := s.Get(ctx, "1"); got.ID != "" {
+		t.Errorf("userService.Get() after Delete = %v, want an empty user", got)
+	}
+
+	if err := s.Update(ctx, &model.User{ID: "2", Name: "B"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("userService.Update() of a missing user error = %v, want sql.ErrNoRows", err)
+	}
+	if _, ok := cache["2"]; ok {
+		t.Error("userService.Update() of a missing user cached it")
+	}
+}
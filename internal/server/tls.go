@@ -0,0 +1,44 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+
+	"go-template/internal/certs"
+	"go-template/internal/config"
+)
+
+// clientAuthTypes maps http.tls.client-auth to the policy of tls.Config.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+func clientAuthType(c config.TLS) tls.ClientAuthType {
+	if c.ClientAuth == "" {
+		if c.ClientCAFile != "" {
+			return tls.RequireAndVerifyClientCert
+		}
+		return tls.NoClientCert
+	}
+	return clientAuthTypes[c.ClientAuth]
+}
+
+// listenTLS wraps listener with TLS when it is enabled. The certificates are
+// reloaded when they change on disk until the returned reloader is closed.
+func listenTLS(listener net.Listener, c config.TLS) (net.Listener, *certs.Reloader, error) {
+	if !c.Enabled() {
+		return listener, nil, nil
+	}
+	reloader, err := certs.NewReloader(c.CertFile, c.KeyFile, c.ClientCAFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := reloader.Watch(); err != nil {
+		return nil, nil, err
+	}
+	return tls.NewListener(listener, reloader.TLSConfig(clientAuthType(c))), reloader, nil
+}
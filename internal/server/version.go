@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go-template/internal/version"
+)
+
+// registerBuildInfo registers the app_build_info gauge with reg, which is
+// always 1 and labelled with the build information so dashboards can tell
+// which build is running. The gauge already registered is reused.
+func registerBuildInfo(reg prometheus.Registerer) {
+	info := version.Get()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "app_build_info",
+		Help: "Build information of the running binary, the value is always 1.",
+	}, []string{"version", "revision", "build_date", "dirty", "go_version"})
+	if err := reg.Register(gauge); err != nil {
+		are, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			panic(err)
+		}
+		gauge = are.ExistingCollector.(*prometheus.GaugeVec)
+	}
+
+	gauge.WithLabelValues(
+		info.Version,
+		info.Revision,
+		info.BuildDate,
+		strconv.FormatBool(info.Dirty),
+		info.GoVersion,
+	).Set(1)
+}
+
+// versionHandler serves the build information as JSON.
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version.Get())
+}
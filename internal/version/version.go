@@ -1,7 +1,67 @@
 package version
 
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// The variables below are injected at build time, e.g.
+//
+//	go build -ldflags "-X go-template/internal/version.VERSION=1.2.0 \
+//		-X go-template/internal/version.REVISION=$(git rev-parse HEAD)"
+//
+// See the build target of the Makefile.
+
 // VERSION information
 var VERSION = "0.0.1"
 
-// REVISION information
+// REVISION information, the commit the binary is built from
 var REVISION = "unknown"
+
+// BUILDDATE information, in RFC 3339 format
+var BUILDDATE = "unknown"
+
+// DIRTY information, "true" if the working tree had uncommitted changes
+var DIRTY = "false"
+
+// Info is the build information of the running binary.
+type Info struct {
+	Version   string   `json:"version"`
+	Revision  string   `json:"revision"`
+	BuildDate string   `json:"buildDate"`
+	Dirty     bool     `json:"dirty"`
+	GoVersion string   `json:"goVersion"`
+	Platform  string   `json:"platform"`
+	Modules   []Module `json:"modules,omitempty"`
+}
+
+// Module is a dependency compiled into the binary.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// Get returns the build information. The Go version and the module
+// versions are read from the runtime.
+func Get() Info {
+	info := Info{
+		Version:   VERSION,
+		Revision:  REVISION,
+		BuildDate: BUILDDATE,
+		Dirty:     DIRTY == "true",
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range bi.Deps {
+			m := Module{Path: dep.Path, Version: dep.Version}
+			if dep.Replace != nil {
+				m.Replace = dep.Replace.Path + "@" + dep.Replace.Version
+			}
+			info.Modules = append(info.Modules, m)
+		}
+	}
+	return info
+}
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS book (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
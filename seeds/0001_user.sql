@@ -0,0 +1,4 @@
+-- sample users for local development
+INSERT IGNORE INTO user (id, name) VALUES
+    ('1', 'A'),
+    ('2', 'B');
@@ -0,0 +1,4 @@
+-- sample books for local development
+INSERT IGNORE INTO book (id, name) VALUES
+    ('1', 'A'),
+    ('2', 'B');